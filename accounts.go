@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	accountsFile        = flag.String("accounts", "", "arquivo YAML com várias contas (nome, credenciais, token, src e dest) sincronizadas uma após a outra, ex.: accounts.yaml")
+	parallelAccounts    = flag.Bool("parallel-accounts", false, "com --accounts, sincroniza todas as contas ao mesmo tempo")
+	credentialsOverride = flag.String("credentials", "", "arquivo de credenciais usado em vez de "+credentialsFile)
+	tokenOverride       = flag.String("token", "", "arquivo de token usado em vez de "+tokenFile)
+)
+
+type account struct {
+	Name        string `yaml:"name"`
+	Credentials string `yaml:"credentials"`
+	Token       string `yaml:"token"`
+	Src         string `yaml:"src"`
+	Dest        string `yaml:"dest"`
+}
+
+var accountFlags = map[string]bool{"accounts": true, "parallel-accounts": true, "credentials": true, "token": true, "src": true, "dest": true}
+
+func loadAccounts(path string) []account {
+	data, error := os.ReadFile(path)
+	if error != nil {
+		fatalf("Não foi possível ler o arquivo de contas '%s': %v", path, error)
+	}
+	var accounts []account
+	if error := yaml.Unmarshal(data, &accounts); error != nil {
+		fatalf("Arquivo de contas inválido '%s': %v", path, error)
+	}
+	if len(accounts) == 0 {
+		fatalf("Nenhuma conta encontrada em '%s'", path)
+	}
+	names := map[string]bool{}
+	for _, account := range accounts {
+		if account.Name == "" || account.Dest == "" {
+			fatalf("Cada conta em '%s' precisa de name e dest", path)
+		}
+		if names[account.Name] {
+			fatalf("Conta '%s' repetida em '%s'", account.Name, path)
+		}
+		names[account.Name] = true
+	}
+	return accounts
+}
+
+func runAccounts() {
+	accounts := loadAccounts(*accountsFile)
+	var forwarded []string
+	flag.CommandLine.Visit(func(f *flag.Flag) {
+		if !accountFlags[f.Name] {
+			forwarded = append(forwarded, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+
+	failed := 0
+	var failedMutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	for _, account := range accounts {
+		waitGroup.Add(1)
+		run := func() {
+			defer waitGroup.Done()
+			if error := runAccount(account, forwarded); error != nil {
+				log.Printf("[%s] a sincronização falhou: %v", account.Name, error)
+				failedMutex.Lock()
+				failed++
+				failedMutex.Unlock()
+			}
+		}
+		if *parallelAccounts {
+			go run()
+		} else {
+			run()
+		}
+	}
+	waitGroup.Wait()
+
+	fmt.Printf("%d contas sincronizadas, %d com falha.\n", len(accounts)-failed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func runAccount(account account, forwarded []string) error {
+	executable, error := os.Executable()
+	if error != nil {
+		return error
+	}
+	accountDir, error := filepath.Abs(sanitizeFileName(account.Name))
+	if error != nil {
+		return error
+	}
+	if error := os.MkdirAll(accountDir, 0755); error != nil {
+		return error
+	}
+
+	credentials := account.Credentials
+	if credentials == "" {
+		credentials = credentialsFile
+	}
+	args := append([]string{}, forwarded...)
+	for name, value := range map[string]string{"credentials": credentials, "token": account.Token, "dest": account.Dest} {
+		if value == "" {
+			continue
+		}
+		absolutePath, error := filepath.Abs(value)
+		if error != nil {
+			return error
+		}
+		args = append(args, "-"+name+"="+absolutePath)
+	}
+	if account.Src != "" {
+		args = append(args, "-src="+account.Src)
+	}
+
+	command := exec.Command(executable, args...)
+	command.Dir = accountDir
+	command.Stdin = os.Stdin
+	stdout, error := command.StdoutPipe()
+	if error != nil {
+		return error
+	}
+	stderr, error := command.StderrPipe()
+	if error != nil {
+		return error
+	}
+	if error := command.Start(); error != nil {
+		return error
+	}
+	var outputs sync.WaitGroup
+	outputs.Add(2)
+	go prefixOutput(&outputs, os.Stdout, stdout, account.Name)
+	go prefixOutput(&outputs, os.Stderr, stderr, account.Name)
+	outputs.Wait()
+	return command.Wait()
+}
+
+func prefixOutput(outputs *sync.WaitGroup, destination io.Writer, source io.Reader, name string) {
+	defer outputs.Done()
+	scanner := bufio.NewScanner(source)
+	scanner.Split(scanOutputLines)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 1 && (line[0] == '\r' || line[0] == '\n') {
+			destination.Write(line)
+			continue
+		}
+		fmt.Fprintf(destination, "[%s] %s", name, line)
+	}
+}
+
+func scanOutputLines(data []byte, atEOF bool) (int, []byte, error) {
+	if index := bytes.IndexAny(data, "\r\n"); index >= 0 {
+		return index + 1, data[:index+1], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	destZip = flag.String("dest-zip", "", "grava todos os arquivos baixados em um único arquivo ZIP em vez de --dest, ex.: backup.zip")
+	destTar = flag.String("dest-tar", "", "grava todos os arquivos baixados em um único arquivo tar.gz em vez de --dest, ex.: backup.tar.gz")
+)
+
+type zipDestination struct {
+	mutex  sync.Mutex
+	path   string
+	file   *os.File
+	writer *zip.Writer
+}
+
+func newZipDestination(path string) (*zipDestination, error) {
+	file, error := os.Create(path + ".tmp")
+	if error != nil {
+		return nil, error
+	}
+	return &zipDestination{path: path, file: file, writer: zip.NewWriter(file)}, nil
+}
+
+func (destination *zipDestination) exists(relativePath string) (bool, error) {
+	return false, nil
+}
+
+func (destination *zipDestination) store(relativePath string, modifiedTime time.Time, localPath string) error {
+	source, error := os.Open(localPath)
+	if error != nil {
+		return error
+	}
+	defer source.Close()
+	header := &zip.FileHeader{Name: filepath.ToSlash(relativePath), Method: zip.Deflate, Modified: modifiedTime}
+	destination.mutex.Lock()
+	defer destination.mutex.Unlock()
+	writer, error := destination.writer.CreateHeader(header)
+	if error != nil {
+		return error
+	}
+	_, error = io.Copy(writer, source)
+	return error
+}
+
+func (destination *zipDestination) close() error {
+	if error := destination.writer.Close(); error != nil {
+		destination.file.Close()
+		return error
+	}
+	if error := destination.file.Close(); error != nil {
+		return error
+	}
+	return os.Rename(destination.path+".tmp", destination.path)
+}
+
+type tarDestination struct {
+	mutex      sync.Mutex
+	path       string
+	file       *os.File
+	compressor *gzip.Writer
+	writer     *tar.Writer
+}
+
+func newTarDestination(path string) (*tarDestination, error) {
+	file, error := os.Create(path + ".tmp")
+	if error != nil {
+		return nil, error
+	}
+	compressor := gzip.NewWriter(file)
+	return &tarDestination{path: path, file: file, compressor: compressor, writer: tar.NewWriter(compressor)}, nil
+}
+
+func (destination *tarDestination) exists(relativePath string) (bool, error) {
+	return false, nil
+}
+
+func (destination *tarDestination) store(relativePath string, modifiedTime time.Time, localPath string) error {
+	source, error := os.Open(localPath)
+	if error != nil {
+		return error
+	}
+	defer source.Close()
+	info, error := source.Stat()
+	if error != nil {
+		return error
+	}
+	header := &tar.Header{Name: filepath.ToSlash(relativePath), Mode: 0644, Size: info.Size(), ModTime: modifiedTime, Typeflag: tar.TypeReg}
+	destination.mutex.Lock()
+	defer destination.mutex.Unlock()
+	if error := destination.writer.WriteHeader(header); error != nil {
+		return error
+	}
+	_, error = io.Copy(destination.writer, source)
+	return error
+}
+
+func (destination *tarDestination) close() error {
+	for _, closer := range []io.Closer{destination.writer, destination.compressor, destination.file} {
+		if error := closer.Close(); error != nil {
+			destination.file.Close()
+			return error
+		}
+	}
+	return os.Rename(destination.path+".tmp", destination.path)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"sort"
+	"sync"
+)
+
+var (
+	checkpointEvery = flag.Int("checkpoint-every", 0, "grava checkpoint.json com os IDs dos arquivos concluídos a cada N arquivos (0 desativa)")
+	resume          = flag.Bool("resume", false, "pula os arquivos que constam em checkpoint.json de uma execução anterior")
+)
+
+const checkpointFile = "checkpoint.json"
+
+type checkpointData struct {
+	Completed []string `json:"completed"`
+}
+
+type checkpointWriter struct {
+	mutex     sync.Mutex
+	path      string
+	every     int
+	resumed   map[string]bool
+	completed map[string]bool
+	pending   int
+}
+
+var checkpoint *checkpointWriter
+
+func openCheckpoint(path string, every int, seed bool) *checkpointWriter {
+	checkpoint := &checkpointWriter{path: path, every: every, resumed: map[string]bool{}, completed: map[string]bool{}}
+	if !seed {
+		return checkpoint
+	}
+	b, error := os.ReadFile(path)
+	if errors.Is(error, os.ErrNotExist) {
+		return checkpoint
+	}
+	if error != nil {
+		fatalf("Não foi possível ler o checkpoint '%s': %v", path, error)
+	}
+	var data checkpointData
+	if error := json.Unmarshal(b, &data); error != nil {
+		fatalf("Checkpoint '%s' inválido: %v", path, error)
+	}
+	for _, fileID := range data.Completed {
+		checkpoint.resumed[fileID] = true
+		checkpoint.completed[fileID] = true
+	}
+	return checkpoint
+}
+
+func (checkpoint *checkpointWriter) done(job *fileJob) bool {
+	if checkpoint == nil || *force || job.replaceExisting {
+		return false
+	}
+	checkpoint.mutex.Lock()
+	defer checkpoint.mutex.Unlock()
+	return checkpoint.resumed[job.file.Id]
+}
+
+func (checkpoint *checkpointWriter) mark(job *fileJob) {
+	if checkpoint == nil {
+		return
+	}
+	checkpoint.mutex.Lock()
+	defer checkpoint.mutex.Unlock()
+	if checkpoint.completed[job.file.Id] {
+		return
+	}
+	checkpoint.completed[job.file.Id] = true
+	checkpoint.pending++
+	if checkpoint.every > 0 && checkpoint.pending >= checkpoint.every {
+		checkpoint.saveLocked()
+	}
+}
+
+func (checkpoint *checkpointWriter) save() {
+	if checkpoint == nil || checkpoint.every == 0 {
+		return
+	}
+	checkpoint.mutex.Lock()
+	defer checkpoint.mutex.Unlock()
+	checkpoint.saveLocked()
+}
+
+func (checkpoint *checkpointWriter) saveLocked() {
+	data := checkpointData{Completed: make([]string, 0, len(checkpoint.completed))}
+	for fileID := range checkpoint.completed {
+		data.Completed = append(data.Completed, fileID)
+	}
+	sort.Strings(data.Completed)
+	b, error := json.MarshalIndent(data, "", "  ")
+	if error != nil {
+		errorLog.Printf("encode checkpoint: %v", error)
+		return
+	}
+	tempPath := checkpoint.path + ".tmp"
+	if error := os.WriteFile(tempPath, b, 0644); error != nil {
+		errorLog.Printf("write '%s': %v", tempPath, error)
+		return
+	}
+	if error := os.Rename(tempPath, checkpoint.path); error != nil {
+		errorLog.Printf("rename '%s': %v", checkpoint.path, error)
+		return
+	}
+	checkpoint.pending = 0
+}
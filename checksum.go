@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/zeebo/blake3"
+)
+
+const (
+	sha256ManifestFile = "sha256sums.txt"
+	blake3ManifestFile = "b3sums.txt"
+)
+
+var hashAlgorithm = flag.String("hash", "", "calcula o hash de cada arquivo baixado e o registra em "+sha256ManifestFile+" ou "+blake3ManifestFile+" na mesma pasta (valores: sha256, blake3)")
+
+var manifestMutex sync.Mutex
+
+func newHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "sha256":
+		return sha256.New()
+	case "blake3":
+		return blake3.New()
+	}
+	return nil
+}
+
+func newManifestHash() hash.Hash {
+	return newHash(*hashAlgorithm)
+}
+
+func manifestFileName() string {
+	if *hashAlgorithm == "blake3" {
+		return blake3ManifestFile
+	}
+	return sha256ManifestFile
+}
+
+func appendToManifest(filePath string, sum []byte) {
+	manifestMutex.Lock()
+	defer manifestMutex.Unlock()
+	manifestPath := filepath.Join(filepath.Dir(filePath), manifestFileName())
+	manifest, error := os.OpenFile(manifestPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if error != nil {
+		errorLog.Printf("open manifest '%s': %v", manifestPath, error)
+		return
+	}
+	defer manifest.Close()
+	if info, error := manifest.Stat(); error == nil && info.Size() == 0 && *hashAlgorithm != "sha256" {
+		fmt.Fprintf(manifest, "# Algorithm: %s\n", *hashAlgorithm)
+	}
+	if _, error := fmt.Fprintf(manifest, "%s  %s\n", hex.EncodeToString(sum), filepath.Base(filePath)); error != nil {
+		errorLog.Printf("write manifest '%s': %v", manifestPath, error)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+type byteSize int64
+
+var errRangeIgnored = errors.New("server ignored the Range header")
+
+var (
+	chunkThreshold = byteSize(50 << 20)
+	chunks         = flag.Int("chunks", 4, "quantidade de partes baixadas em paralelo para arquivos maiores que --chunk-threshold")
+)
+
+func init() {
+	flag.Var(&chunkThreshold, "chunk-threshold", "tamanho a partir do qual o arquivo é baixado em partes paralelas, ex.: 50MB (0 desativa)")
+}
+
+func (size *byteSize) String() string {
+	return strconv.FormatInt(int64(*size), 10)
+}
+
+func (size *byteSize) Set(value string) error {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = strings.TrimSpace(number), unit.multiplier
+			break
+		}
+	}
+	number, error := strconv.ParseFloat(value, 64)
+	if error != nil || number < 0 {
+		return fmt.Errorf("tamanho inválido: '%s'", value)
+	}
+	*size = byteSize(number * float64(multiplier))
+	return nil
+}
+
+func downloadFileChunked(ctx context.Context, client *driveClient, f *drive.File, tempFilePath string) ([]byte, error) {
+	results := make(chan error, *chunks)
+	var chunkError error
+	out, error := os.Create(tempFilePath)
+	if error != nil {
+		return nil, fmt.Errorf("create temp '%s': %w", tempFilePath, error)
+	}
+	defer out.Close()
+
+	chunkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chunkSize := (f.Size + int64(*chunks) - 1) / int64(*chunks)
+	started := 0
+	for start := int64(0); start < f.Size; start += chunkSize {
+		end := min(start+chunkSize, f.Size) - 1
+		started++
+		go func() { results <- downloadChunk(chunkCtx, client, f, out, start, end) }()
+	}
+	for range started {
+		if error := <-results; error != nil && chunkError == nil {
+			chunkError = error
+			cancel()
+		}
+	}
+	if chunkError != nil {
+		out.Close()
+		os.Remove(tempFilePath)
+		if errors.Is(chunkError, errRangeIgnored) {
+			return downloadFileStream(ctx, client, f, tempFilePath)
+		}
+		return nil, chunkError
+	}
+
+	if info, error := out.Stat(); error != nil || info.Size() != f.Size {
+		out.Close()
+		os.Remove(tempFilePath)
+		if error == nil {
+			error = fmt.Errorf("size mismatch: expected %d, got %d", f.Size, info.Size())
+		}
+		return nil, error
+	}
+	if _, error := out.Seek(0, io.SeekStart); error != nil {
+		out.Close()
+		os.Remove(tempFilePath)
+		return nil, error
+	}
+	md5Hash := md5.New()
+	manifestHash := newManifestHash()
+	writer := io.Writer(md5Hash)
+	if manifestHash != nil {
+		writer = io.MultiWriter(md5Hash, manifestHash)
+	}
+	if _, error := io.Copy(writer, out); error != nil {
+		out.Close()
+		os.Remove(tempFilePath)
+		return nil, error
+	}
+	if sum := hex.EncodeToString(md5Hash.Sum(nil)); f.Md5Checksum != "" && sum != f.Md5Checksum {
+		out.Close()
+		os.Remove(tempFilePath)
+		return nil, fmt.Errorf("md5 mismatch: expected %s, got %s", f.Md5Checksum, sum)
+	}
+	if manifestHash == nil {
+		return nil, nil
+	}
+	return manifestHash.Sum(nil), nil
+}
+
+func downloadChunk(ctx context.Context, client *driveClient, f *drive.File, out *os.File, start, end int64) error {
+	if error := client.wait(ctx); error != nil {
+		return error
+	}
+	call := client.service.Files.Get(f.Id).SupportsAllDrives(true).Context(ctx)
+	setResourceKeyHeader(call.Header(), f)
+	call.Header().Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	resp, error := call.Download()
+	client.done(error)
+	if error != nil {
+		return error
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return errRangeIgnored
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("range request for bytes %d-%d returned HTTP %d", start, end, resp.StatusCode)
+	}
+	written, error := io.Copy(io.NewOffsetWriter(out, start), withActivity(ctx, resp.Body))
+	if error != nil {
+		return fmt.Errorf("copy bytes %d-%d: %w", start, end, error)
+	}
+	if written != end-start+1 {
+		return fmt.Errorf("short chunk: expected %d bytes at offset %d, got %d", end-start+1, start, written)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+var errCircuitOpen = errors.New("circuit breaker aberto: a API do Drive retornou erros 5xx consecutivos")
+
+type circuitBreaker struct {
+	mutex        sync.Mutex
+	state        circuitState
+	failures     int
+	tripCount    int
+	openDuration time.Duration
+	openedAt     time.Time
+	probing      bool
+}
+
+func newCircuitBreaker(tripCount int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{tripCount: tripCount, openDuration: openDuration}
+}
+
+func (breaker *circuitBreaker) allow() error {
+	if breaker.tripCount <= 0 {
+		return nil
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	switch breaker.state {
+	case circuitOpen:
+		if time.Since(breaker.openedAt) < breaker.openDuration {
+			return errCircuitOpen
+		}
+		breaker.state = circuitHalfOpen
+		breaker.probing = true
+	case circuitHalfOpen:
+		if breaker.probing {
+			return errCircuitOpen
+		}
+		breaker.probing = true
+	}
+	return nil
+}
+
+func (breaker *circuitBreaker) retryAfter() time.Duration {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if breaker.state == circuitOpen {
+		if remaining := breaker.openDuration - time.Since(breaker.openedAt); remaining > 0 {
+			return remaining
+		}
+	}
+	return time.Second
+}
+
+func (breaker *circuitBreaker) record(error error) {
+	if breaker.tripCount <= 0 {
+		return
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if errors.Is(error, context.Canceled) || errors.Is(error, context.DeadlineExceeded) {
+		breaker.probing = false
+		return
+	}
+	if apiErrorCode(error) >= http.StatusInternalServerError {
+		breaker.failures++
+		if breaker.state == circuitHalfOpen || breaker.failures >= breaker.tripCount {
+			breaker.state = circuitOpen
+			breaker.openedAt = time.Now()
+			breaker.failures = 0
+			breaker.probing = false
+		}
+		return
+	}
+	breaker.state = circuitClosed
+	breaker.failures = 0
+	breaker.probing = false
+}
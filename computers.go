@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"google.golang.org/api/drive/v3"
+)
+
+var computers = flag.Bool("computers", false, "também baixa a seção \"Computadores\" (backups do Google Drive para computador) em --dest/Computers")
+
+func resolveComputersFolder(driveService *drive.Service) string {
+	query := "mimeType='application/vnd.google-apps.folder' and name='Computers' and 'root' in parents and trashed=false"
+	fileList, error := listFiles(driveService).Q(query).Fields("files(id)").PageSize(1).Do()
+	if error != nil {
+		log.Printf("ao buscar a seção Computadores: %v", error)
+		return ""
+	}
+	if len(fileList.Files) == 0 {
+		log.Printf("a seção Computadores não foi encontrada no Drive")
+		return ""
+	}
+	return fileList.Files[0].Id
+}
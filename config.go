@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+var profile = flag.String("profile", "", "nome do perfil cuja configuração será usada (~/.config/godrive/profiles/<nome>/config.yaml)")
+
+func configDir() string {
+	dir, error := os.UserConfigDir()
+	if error != nil {
+		fatalf("Não foi possível localizar o diretório de configuração: %v", error)
+	}
+	return filepath.Join(dir, "godrive")
+}
+
+func profileDir(name string) string {
+	return filepath.Join(configDir(), "profiles", name)
+}
+
+func parseFlags(flagSet *flag.FlagSet, args []string) {
+	flagSet.Parse(args)
+	loadConfig(flagSet)
+	if *profile != "" {
+		tokenFile = filepath.Join(profileDir(*profile), "token.json")
+	}
+	if *credentialsOverride != "" {
+		credentialsFile = *credentialsOverride
+	}
+	if *tokenOverride != "" {
+		tokenFile = *tokenOverride
+	}
+	openLogFiles()
+}
+
+func configPath() string {
+	if *profile != "" {
+		path := filepath.Join(profileDir(*profile), "config.yaml")
+		if _, error := os.Stat(path); error == nil {
+			return path
+		}
+	}
+	return filepath.Join(configDir(), "config.yaml")
+}
+
+func loadConfig(flagSet *flag.FlagSet) {
+	path := configPath()
+	data, error := os.ReadFile(path)
+	if os.IsNotExist(error) {
+		return
+	}
+	if error != nil {
+		fatalf("Não foi possível ler o arquivo de configuração '%s': %v", path, error)
+	}
+	var values map[string]any
+	if error := yaml.Unmarshal(data, &values); error != nil {
+		fatalf("Arquivo de configuração inválido '%s': %v", path, error)
+	}
+
+	explicit := map[string]bool{}
+	flagSet.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for name, value := range values {
+		if explicit[name] || name == "profile" {
+			continue
+		}
+		if flagSet.Lookup(name) == nil {
+			continue
+		}
+		if error := flagSet.Set(name, fmt.Sprint(value)); error != nil {
+			fatalf("Valor inválido para '%s' em '%s': %v", name, path, error)
+		}
+	}
+}
+
+func runProfile(args []string) {
+	if len(args) == 0 || args[0] != "create" {
+		fmt.Fprintln(os.Stderr, "Uso: godrive profile create --profile nome")
+		os.Exit(2)
+	}
+	flagSet := newCommandFlagSet("profile create")
+	parseFlags(flagSet, args[1:])
+	if *profile == "" {
+		fmt.Fprintln(os.Stderr, "Uso: godrive profile create --profile nome")
+		os.Exit(2)
+	}
+
+	dir := profileDir(*profile)
+	path := filepath.Join(dir, "config.yaml")
+	if _, error := os.Stat(path); error == nil {
+		fatalf("O perfil '%s' já existe: %s", *profile, path)
+	}
+	values := map[string]string{}
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if f.Name != "profile" {
+			values[f.Name] = f.Value.String()
+		}
+	})
+	data, error := yaml.Marshal(values)
+	if error != nil {
+		fatalf("Não foi possível gerar a configuração do perfil: %v", error)
+	}
+	if error := os.MkdirAll(dir, 0700); error != nil {
+		fatalf("Não foi possível criar o diretório do perfil '%s': %v", dir, error)
+	}
+	if error := os.WriteFile(path, data, 0600); error != nil {
+		fatalf("Não foi possível salvar a configuração do perfil: %v", error)
+	}
+	fmt.Printf("Perfil '%s' criado em: %s\n", *profile, path)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"crypto/md5"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+)
+
+var conflictStrategy = flag.String("conflict", "skip", "o que fazer quando o arquivo local já existe e é diferente do Drive: overwrite, skip, rename ou ask")
+
+var (
+	conflictPromptMutex sync.Mutex
+	conflictPromptInput = bufio.NewReader(os.Stdin)
+)
+
+func resolveConflict(file *drive.File, filePath string) string {
+	if *forceIfChanged {
+		if localFileChanged(file, filePath) {
+			return filePath
+		}
+		return ""
+	}
+	if *conflictStrategy == "skip" {
+		return ""
+	}
+	if file.Md5Checksum != "" {
+		if sum, error := hashFile(filePath, md5.New()); error == nil && sum == file.Md5Checksum {
+			return ""
+		}
+	} else if localFileNewer(file, filePath) {
+		return ""
+	}
+	switch *conflictStrategy {
+	case "overwrite":
+		return filePath
+	case "rename":
+		return conflictPath(filePath)
+	case "ask":
+		return askConflict(filePath)
+	}
+	return ""
+}
+
+func localFileNewer(file *drive.File, filePath string) bool {
+	info, error := os.Stat(filePath)
+	if error != nil {
+		return false
+	}
+	modifiedTime, error := time.Parse(time.RFC3339, file.ModifiedTime)
+	return error == nil && !info.ModTime().Before(modifiedTime)
+}
+
+func conflictPath(filePath string) string {
+	extension := filepath.Ext(filePath)
+	return strings.TrimSuffix(filePath, extension) + "_conflict_" + time.Now().Format("20060102-150405") + extension
+}
+
+func askConflict(filePath string) string {
+	if info, error := os.Stdin.Stat(); error != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return ""
+	}
+	conflictPromptMutex.Lock()
+	defer conflictPromptMutex.Unlock()
+	for {
+		fmt.Fprintf(os.Stderr, "\nConflito: '%s' já existe e é diferente do Drive. [s]obrescrever, [p]ular ou [r]enomear? ", filePath)
+		answer, error := conflictPromptInput.ReadString('\n')
+		if error != nil {
+			return ""
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "s":
+			return filePath
+		case "p":
+			return ""
+		case "r":
+			return conflictPath(filePath)
+		}
+	}
+}
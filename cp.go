@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func runCp(args []string) {
+	flagSet := newCommandFlagSet("cp")
+	fileID := flagSet.String("file-id", "", "ID do arquivo no Drive a ser copiado")
+	destFolderID := flagSet.String("dest-folder-id", "", "ID da pasta do Drive onde a cópia será criada")
+	name := flagSet.String("name", "", "nome da cópia (por padrão, o Drive usa \"Cópia de <nome>\")")
+	parseFlags(flagSet, args)
+	if *fileID == "" || *destFolderID == "" {
+		fmt.Fprintln(os.Stderr, "Uso: godrive cp --file-id ID --dest-folder-id ID [--name novo-nome]")
+		os.Exit(2)
+	}
+
+	driveService := authenticateWithScope(context.Background(), drive.DriveScope)
+	copied, error := driveService.Files.Copy(*fileID, &drive.File{Name: *name, Parents: []string{*destFolderID}}).SupportsAllDrives(true).Fields("id, name").Do()
+	if error != nil {
+		errorLog.Printf("copy '%s' to folder '%s': %s", *fileID, *destFolderID, formatAPIError(error))
+		fatalf("Não foi possível copiar o arquivo '%s': %v", *fileID, error)
+	}
+	fmt.Printf("Cópia criada: '%s' (%s)\n", copied.Name, copied.Id)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+func credentialType(path string) (string, error) {
+	b, error := os.ReadFile(path)
+	if error != nil {
+		return "", error
+	}
+	var credentials map[string]json.RawMessage
+	if error := json.Unmarshal(b, &credentials); error != nil {
+		return "", fmt.Errorf("'%s' não é um JSON válido: %v", path, error)
+	}
+	if typeJSON, ok := credentials["type"]; ok {
+		var credentialType string
+		if error := json.Unmarshal(typeJSON, &credentialType); error != nil {
+			return "", fmt.Errorf("o campo \"type\" de '%s' é inválido: %v", path, error)
+		}
+		return credentialType, nil
+	}
+	if _, ok := credentials["installed"]; ok {
+		return "installed", nil
+	}
+	if _, ok := credentials["web"]; ok {
+		return "web", nil
+	}
+	return "", fmt.Errorf("não foi possível identificar o tipo de credencial em '%s'", path)
+}
+
+func checkCredentials(path string) (string, error) {
+	kind, error := credentialType(path)
+	if error != nil {
+		return "", error
+	}
+	b, error := os.ReadFile(path)
+	if error != nil {
+		return "", error
+	}
+	switch kind {
+	case "service_account":
+		_, error = google.JWTConfigFromJSON(b)
+	case "authorized_user":
+		_, error = google.CredentialsFromJSON(context.Background(), b)
+	case "installed", "web":
+		error = validateCredentials(path)
+	default:
+		error = fmt.Errorf("tipo de credencial não suportado em '%s': %s", path, kind)
+	}
+	return kind, error
+}
+
+func credentialsClient(ctx context.Context, scopes []string) (*http.Client, error) {
+	kind, error := checkCredentials(credentialsFile)
+	if error != nil {
+		return nil, fmt.Errorf("arquivo de credenciais inválido: %w", error)
+	}
+	b, error := os.ReadFile(credentialsFile)
+	if error != nil {
+		return nil, fmt.Errorf("não foi possível ler o arquivo de credenciais (%s): %w", credentialsFile, error)
+	}
+	switch kind {
+	case "service_account":
+		config, error := google.JWTConfigFromJSON(b, scopes...)
+		if error != nil {
+			return nil, fmt.Errorf("não foi possível processar a conta de serviço: %w", error)
+		}
+		return config.Client(httpClientContext()), nil
+	case "authorized_user":
+		credentials, error := google.CredentialsFromJSON(httpClientContext(), b, scopes...)
+		if error != nil {
+			return nil, fmt.Errorf("não foi possível processar as credenciais do usuário: %w", error)
+		}
+		return oauth2.NewClient(httpClientContext(), credentials.TokenSource), nil
+	}
+	config, error := google.ConfigFromJSON(b, scopes...)
+	if error != nil {
+		return nil, fmt.Errorf("não foi possível processar o arquivo de credenciais: %w", error)
+	}
+	return getClient(ctx, config, scopeTokenFile(scopes))
+}
+
+func validateCredentials(path string) error {
+	b, error := os.ReadFile(path)
+	if error != nil {
+		return error
+	}
+	var credentials map[string]json.RawMessage
+	if error := json.Unmarshal(b, &credentials); error != nil {
+		return fmt.Errorf("'%s' não é um JSON válido: %v", path, error)
+	}
+	installedJSON, ok := credentials["installed"]
+	if !ok {
+		if _, ok := credentials["web"]; ok {
+			return fmt.Errorf("'%s' é de um cliente OAuth do tipo \"Aplicativo da Web\"; crie um ID do cliente do tipo \"App para computador\"", path)
+		}
+		return fmt.Errorf("'%s' não tem o campo \"installed\"; baixe o JSON de um ID do cliente OAuth do tipo \"App para computador\"", path)
+	}
+	var installed map[string]any
+	if error := json.Unmarshal(installedJSON, &installed); error != nil {
+		return fmt.Errorf("o campo \"installed\" de '%s' é inválido: %v", path, error)
+	}
+	var missing []string
+	for _, field := range []string{"client_id", "client_secret", "auth_uri", "token_uri"} {
+		if value, _ := installed[field].(string); value == "" {
+			missing = append(missing, "installed."+field)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("'%s' não tem os campos obrigatórios: %s", path, strings.Join(missing, ", "))
+	}
+	return nil
+}
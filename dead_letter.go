@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+)
+
+var deadLetters *deadLetterQueue
+
+type deadLetterEntry struct {
+	FileID      string    `json:"file_id"`
+	Name        string    `json:"name"`
+	MimeType    string    `json:"mime_type"`
+	ResourceKey string    `json:"resource_key,omitempty"`
+	LocalPath   string    `json:"local_path"`
+	LastError   string    `json:"last_error"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+type deadLetterQueue struct {
+	mutex   sync.Mutex
+	path    string
+	entries map[string]deadLetterEntry
+}
+
+func loadDeadLetterQueue(path string) *deadLetterQueue {
+	queue := &deadLetterQueue{path: path, entries: map[string]deadLetterEntry{}}
+	b, error := os.ReadFile(path)
+	if os.IsNotExist(error) {
+		return queue
+	}
+	if error != nil {
+		fatalf("Não foi possível ler '%s': %v", path, error)
+	}
+	var entries []deadLetterEntry
+	if error := json.Unmarshal(b, &entries); error != nil {
+		fatalf("Não foi possível processar '%s': %v", path, error)
+	}
+	for _, entry := range entries {
+		queue.entries[entry.FileID] = entry
+	}
+	return queue
+}
+
+func (queue *deadLetterQueue) add(job *fileJob, lastError error) {
+	if queue == nil {
+		return
+	}
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	queue.entries[job.file.Id] = deadLetterEntry{
+		FileID:      job.file.Id,
+		Name:        job.file.Name,
+		MimeType:    job.file.MimeType,
+		ResourceKey: job.file.ResourceKey,
+		LocalPath:   job.localPath,
+		LastError:   lastError.Error(),
+		Timestamp:   time.Now(),
+	}
+	queue.save()
+}
+
+func (queue *deadLetterQueue) remove(fileID string) {
+	if queue == nil {
+		return
+	}
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if _, ok := queue.entries[fileID]; !ok {
+		return
+	}
+	delete(queue.entries, fileID)
+	queue.save()
+}
+
+func (queue *deadLetterQueue) list() []deadLetterEntry {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	return queue.sortedEntries()
+}
+
+func (queue *deadLetterQueue) sortedEntries() []deadLetterEntry {
+	entries := make([]deadLetterEntry, 0, len(queue.entries))
+	for _, entry := range queue.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LocalPath < entries[j].LocalPath })
+	return entries
+}
+
+func (queue *deadLetterQueue) save() {
+	b, error := json.MarshalIndent(queue.sortedEntries(), "", "  ")
+	if error != nil {
+		errorLog.Printf("encode dead letter queue: %v", error)
+		return
+	}
+	tempPath := queue.path + ".tmp"
+	if error := os.WriteFile(tempPath, b, 0644); error != nil {
+		errorLog.Printf("write '%s': %v", tempPath, error)
+		return
+	}
+	if error := os.Rename(tempPath, queue.path); error != nil {
+		errorLog.Printf("rename '%s': %v", queue.path, error)
+	}
+}
+
+func runRetryDeadLetter(args []string) {
+	flagSet := newCommandFlagSet("retry-dead-letter")
+	file := flagSet.String("file", deadLetterFile, "arquivo com os arquivos que esgotaram as tentativas")
+	parseFlags(flagSet, args)
+
+	deadLetters = loadDeadLetterQueue(*file)
+	entries := deadLetters.list()
+	if len(entries) == 0 {
+		fmt.Printf("Nenhum arquivo para tentar novamente em '%s'.\n", *file)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelRun = cancel
+
+	driveService := authenticate(ctx)
+	client := newDriveClient(driveService, *apiRequestsPerSecond)
+
+	fmt.Printf("Tentando novamente %d arquivos de '%s'...\n", len(entries), *file)
+	runSync(ctx, client, func(channelFileJob chan<- *fileJob, statusTracker *statusTracker) {
+		for _, entry := range entries {
+			if error := os.MkdirAll(filepath.Dir(entry.LocalPath), 0755); error != nil {
+				log.Printf("ao criar diretório local '%s': %v", filepath.Dir(entry.LocalPath), error)
+				abortOnDiskError(error)
+				continue
+			}
+			statusTracker.totalFilesFound.Add(1)
+			channelFileJob <- &fileJob{
+				file:      &drive.File{Id: entry.FileID, Name: entry.Name, MimeType: entry.MimeType, ResourceKey: entry.ResourceKey},
+				localPath: entry.LocalPath,
+			}
+		}
+	})
+}
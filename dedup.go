@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/md5"
+	"flag"
+	"log"
+	"os"
+	"sync"
+
+	"google.golang.org/api/drive/v3"
+)
+
+var dedupHardlinks = flag.Bool("dedup-hardlinks", false, "cria hard links em vez de baixar novamente arquivos com o mesmo MD5 (origem e destino precisam estar no mesmo sistema de arquivos)")
+
+var (
+	downloadedByMD5Mutex sync.Mutex
+	downloadedByMD5      = map[string]string{}
+)
+
+func rememberDownloadedFile(file *drive.File, filePath string) {
+	if !*dedupHardlinks || file.Md5Checksum == "" {
+		return
+	}
+	downloadedByMD5Mutex.Lock()
+	defer downloadedByMD5Mutex.Unlock()
+	if _, ok := downloadedByMD5[file.Md5Checksum]; !ok {
+		downloadedByMD5[file.Md5Checksum] = filePath
+	}
+}
+
+func rememberExistingFile(file *drive.File, filePath string) {
+	if !*dedupHardlinks || file.Md5Checksum == "" {
+		return
+	}
+	if sum, error := hashFile(filePath, md5.New()); error == nil && sum == file.Md5Checksum {
+		rememberDownloadedFile(file, filePath)
+	}
+}
+
+func linkToIdenticalFile(file *drive.File, filePath string) bool {
+	if !*dedupHardlinks || file.Md5Checksum == "" {
+		return false
+	}
+	downloadedByMD5Mutex.Lock()
+	existingPath, ok := downloadedByMD5[file.Md5Checksum]
+	downloadedByMD5Mutex.Unlock()
+	if !ok || existingPath == filePath {
+		return false
+	}
+	if error := os.Link(existingPath, filePath); error != nil {
+		skippedLog.Printf("hard link '%s' -> '%s' failed, downloading instead: %v", filePath, existingPath, error)
+		return false
+	}
+	log.Printf("%s (hard link para %s)", filePath, existingPath)
+	return true
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type destination interface {
+	exists(relativePath string) (bool, error)
+	store(relativePath string, modifiedTime time.Time, localPath string) error
+	close() error
+}
+
+var outputDestination destination
+
+func validateDestination() {
+	var selected []string
+	for name, value := range map[string]string{"--dest-zip": *destZip, "--dest-tar": *destTar, "--dest-webdav": *destWebDAV, "--dest-sftp": *destSFTP} {
+		if value != "" {
+			selected = append(selected, name)
+		}
+	}
+	sort.Strings(selected)
+	if len(selected) > 1 {
+		fatalf("Use apenas um entre %s", strings.Join(selected, ", "))
+	}
+	if len(selected) == 1 && *watch {
+		fatalf("%s não pode ser usado com --watch", selected[0])
+	}
+}
+
+func openDestination() {
+	var error error
+	switch {
+	case *destZip != "":
+		outputDestination, error = newZipDestination(*destZip)
+	case *destTar != "":
+		outputDestination, error = newTarDestination(*destTar)
+	case *destWebDAV != "":
+		outputDestination, error = newWebDAVDestination(*destWebDAV)
+	case *destSFTP != "":
+		outputDestination, error = newSFTPDestination(*destSFTP)
+	default:
+		return
+	}
+	if error != nil {
+		fatalf("Não foi possível abrir o destino: %v", error)
+	}
+	if *dedupHardlinks {
+		log.Printf("aviso: --dedup-hardlinks não funciona com --dest-zip, --dest-tar, --dest-webdav ou --dest-sftp e foi desativado")
+		*dedupHardlinks = false
+	}
+	stagingPath, error := os.MkdirTemp("", "godrive-")
+	if error != nil {
+		fatalf("Não foi possível criar a pasta temporária: %v", error)
+	}
+	*destinationPath = stagingPath
+}
+
+func existsInDestination(job *fileJob, filePath string) bool {
+	if outputDestination == nil || job.replaceExisting || *force {
+		return false
+	}
+	relativePath, error := filepath.Rel(*destinationPath, filePath)
+	if error != nil {
+		return false
+	}
+	exists, error := outputDestination.exists(relativePath)
+	if error != nil {
+		errorLog.Printf("check '%s' in destination: %v", relativePath, error)
+	}
+	return exists
+}
+
+func storeInDestination(job *fileJob, filePath string) error {
+	if outputDestination == nil {
+		return nil
+	}
+	relativePath, error := filepath.Rel(*destinationPath, filePath)
+	if error != nil {
+		return error
+	}
+	modifiedTime, error := time.Parse(time.RFC3339, job.file.ModifiedTime)
+	if error != nil {
+		modifiedTime = time.Now()
+	}
+	if error := outputDestination.store(relativePath, modifiedTime, filePath); error != nil {
+		errorLog.Printf("store '%s': %v", relativePath, error)
+		return error
+	}
+	return os.Remove(filePath)
+}
+
+func closeDestination() {
+	if outputDestination == nil {
+		return
+	}
+	error := filepath.WalkDir(*destinationPath, func(path string, entry fs.DirEntry, error error) error {
+		if error != nil || entry.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return error
+		}
+		info, error := entry.Info()
+		if error != nil {
+			return error
+		}
+		relativePath, error := filepath.Rel(*destinationPath, path)
+		if error != nil {
+			return error
+		}
+		return outputDestination.store(relativePath, info.ModTime(), path)
+	})
+	if error != nil {
+		errorLog.Printf("store remaining files: %v", error)
+	}
+	if error := outputDestination.close(); error != nil {
+		fatalf("Não foi possível finalizar o destino: %v", error)
+	}
+	os.RemoveAll(*destinationPath)
+}
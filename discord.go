@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var discordWebhook = flag.String("discord-webhook", "", "URL de webhook do Discord que recebe o resumo ao final de cada sincronização, ex.: https://discord.com/api/webhooks/...")
+
+const (
+	discordColorSuccess = 0x2ecc71
+	discordColorFailure = 0xe74c3c
+)
+
+var (
+	discordClient           = &http.Client{Timeout: notificationTimeout}
+	discordNotificationSent bool
+)
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbed struct {
+	Title     string         `json:"title"`
+	Color     int            `json:"color"`
+	Fields    []discordField `json:"fields"`
+	Timestamp string         `json:"timestamp"`
+}
+
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+func sendDiscordNotification(statusTracker *statusTracker, success bool) {
+	if *discordWebhook == "" || !shouldNotify(&discordNotificationSent, success) {
+		return
+	}
+	summary := statusTracker.summary()
+	embed := discordEmbed{Title: "Sincronização concluída com sucesso", Color: discordColorSuccess, Timestamp: summary.GeneratedAt.Format(time.RFC3339)}
+	if !success {
+		embed.Title = "Sincronização concluída com falhas"
+		embed.Color = discordColorFailure
+	}
+	embed.Fields = []discordField{
+		{Name: "Concluídos", Value: fmt.Sprint(summary.Completed), Inline: true},
+		{Name: "Pulados", Value: fmt.Sprint(summary.Skipped), Inline: true},
+		{Name: "Restritos", Value: fmt.Sprint(summary.Restricted), Inline: true},
+		{Name: "Falhas", Value: fmt.Sprint(summary.Failed), Inline: true},
+		{Name: "Duração", Value: summary.Duration.String(), Inline: true},
+		{Name: "Vazão", Value: fmt.Sprintf("%.2f arquivos/s", summary.Throughput), Inline: true},
+	}
+	body, error := json.Marshal(discordMessage{Embeds: []discordEmbed{embed}})
+	if error != nil {
+		errorLog.Printf("encode Discord message: %v", error)
+		return
+	}
+	response, error := discordClient.Post(*discordWebhook, "application/json", bytes.NewReader(body))
+	if error != nil {
+		errorLog.Printf("send Discord notification: %v", error)
+		return
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		errorLog.Printf("send Discord notification: %s", response.Status)
+	}
+}
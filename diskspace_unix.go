@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import "golang.org/x/sys/unix"
+
+func freeDiskSpace(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if error := unix.Statfs(path, &stat); error != nil {
+		return 0, error
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
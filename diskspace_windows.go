@@ -0,0 +1,15 @@
+package main
+
+import "golang.org/x/sys/windows"
+
+func freeDiskSpace(path string) (uint64, error) {
+	pathPointer, error := windows.UTF16PtrFromString(path)
+	if error != nil {
+		return 0, error
+	}
+	var freeBytes uint64
+	if error := windows.GetDiskFreeSpaceEx(pathPointer, &freeBytes, nil, nil); error != nil {
+		return 0, error
+	}
+	return freeBytes, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
+)
+
+type doctorCheck struct {
+	description string
+	run         func() error
+}
+
+func runDoctor(args []string) {
+	parseFlags(newCommandFlagSet("doctor"), args)
+	ctx := context.Background()
+
+	scopes := requestedScopes()
+	tokenPath := scopeTokenFile(scopes)
+	if *rcloneConfig != "" {
+		tokenPath = *rcloneConfig
+	}
+
+	var (
+		kind         string
+		config       *oauth2.Config
+		storedToken  *oauth2.Token
+		tokenValid   bool
+		driveService *drive.Service
+		about        *drive.About
+	)
+	checks := []doctorCheck{
+		{"Arquivo de credenciais (" + credentialsFile + ")", func() error {
+			var error error
+			if *rcloneConfig != "" {
+				config, storedToken, error = rcloneCredentials(scopes)
+				return error
+			}
+			kind, error = checkCredentials(credentialsFile)
+			if error != nil || kind != "installed" {
+				return error
+			}
+			b, error := os.ReadFile(credentialsFile)
+			if error != nil {
+				return error
+			}
+			config, error = google.ConfigFromJSON(b, scopes...)
+			if error != nil {
+				return error
+			}
+			if config.ClientID == "" || config.ClientSecret == "" {
+				return fmt.Errorf("client_id ou client_secret ausente")
+			}
+			return nil
+		}},
+		{"Renovação do token de acesso (" + tokenPath + ")", func() error {
+			if kind == "service_account" || kind == "authorized_user" {
+				tokenValid = true
+				return nil
+			}
+			if config == nil {
+				return fmt.Errorf("requer um arquivo de credenciais válido")
+			}
+			if storedToken == nil {
+				var error error
+				if storedToken, error = tokenFromFile(tokenPath); error != nil {
+					return error
+				}
+			}
+			if _, error := config.TokenSource(ctx, storedToken).Token(); error != nil {
+				return error
+			}
+			tokenValid = true
+			return nil
+		}},
+		{"Conexão com a API do Drive", func() error {
+			if !tokenValid {
+				return fmt.Errorf("requer um token de acesso válido")
+			}
+			client, error := newAuthenticatedClient(ctx, scopes)
+			if error != nil {
+				return error
+			}
+			if driveService, error = driveServiceForClient(ctx, client); error != nil {
+				return error
+			}
+			about, error = driveService.About.Get().Fields("user, storageQuota").Do()
+			return error
+		}},
+		{fmt.Sprintf("Pasta de origem no Drive ('%s')", *sourcePath), func() error {
+			if driveService == nil {
+				return fmt.Errorf("requer conexão com a API do Drive")
+			}
+			_, error := getDriveFolderIDByPath(driveService, *sourcePath)
+			return error
+		}},
+		{fmt.Sprintf("Permissão de escrita no destino ('%s')", *destinationPath), func() error {
+			if error := os.MkdirAll(*destinationPath, 0755); error != nil {
+				return error
+			}
+			probe, error := os.CreateTemp(*destinationPath, ".godrive-doctor-*")
+			if error != nil {
+				return error
+			}
+			probe.Close()
+			return os.Remove(probe.Name())
+		}},
+		{"Espaço livre no destino (estimativa pelo uso de toda a conta, não só de --src)", func() error {
+			free, error := freeDiskSpace(*destinationPath)
+			if error != nil {
+				return error
+			}
+			if about == nil || about.StorageQuota == nil {
+				return fmt.Errorf("requer conexão com a API do Drive")
+			}
+			if needed := uint64(about.StorageQuota.UsageInDrive); free < needed {
+				return fmt.Errorf("%.2f GB livres, a conta usa %.2f GB no Drive", gigabytes(free), gigabytes(needed))
+			}
+			return nil
+		}},
+	}
+
+	failed := false
+	for _, check := range checks {
+		if error := check.run(); error != nil {
+			failed = true
+			fmt.Printf("✗ %s: %v\n", check.description, error)
+		} else {
+			fmt.Printf("✓ %s\n", check.description)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func gigabytes(bytes uint64) float64 {
+	return float64(bytes) / (1 << 30)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"math"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/drive/v3"
+)
+
+var (
+	apiRequestsPerSecond = flag.Float64("api-rps", 10, "limite de requisições por segundo à API do Drive (0 desativa o limite)")
+	circuitTripCount     = flag.Int("circuit-trip-count", 10, "quantidade de erros 5xx consecutivos da API que interrompe as chamadas temporariamente (0 desativa)")
+	circuitOpenDuration  = flag.Duration("circuit-open-duration", 30*time.Second, "tempo em que as chamadas à API ficam interrompidas antes de uma nova tentativa")
+	maxIdleConns         = flag.Int("max-idle-conns", 200, "quantidade máxima de conexões HTTP ociosas mantidas para reutilização")
+	maxConnsPerHost      = flag.Int("max-conns-per-host", 100, "quantidade máxima de conexões HTTP simultâneas por host (0 para ilimitado)")
+)
+
+type driveClient struct {
+	service *drive.Service
+	limiter *rate.Limiter
+	breaker *circuitBreaker
+}
+
+func newDriveClient(service *drive.Service, requestsPerSecond float64) *driveClient {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if requestsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), int(math.Max(1, requestsPerSecond)))
+	}
+	return &driveClient{service: service, limiter: limiter, breaker: newCircuitBreaker(*circuitTripCount, *circuitOpenDuration)}
+}
+
+func newHTTPTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = *maxIdleConns
+	transport.MaxIdleConnsPerHost = *maxIdleConns
+	transport.MaxConnsPerHost = *maxConnsPerHost
+	return transport
+}
+
+func httpClientContext() context.Context {
+	return context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: newHTTPTransport()})
+}
+
+func (client *driveClient) wait(ctx context.Context) error {
+	if error := client.breaker.allow(); error != nil {
+		return error
+	}
+	if error := client.limiter.Wait(ctx); error != nil {
+		client.breaker.record(error)
+		return error
+	}
+	return nil
+}
+
+func (client *driveClient) done(error error) {
+	client.breaker.record(error)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+)
+
+func runDu(args []string) {
+	flagSet := newCommandFlagSet("du")
+	humanReadable := flagSet.Bool("human-readable", false, "mostra os tamanhos em K, M, G e T em vez de bytes")
+	parseFlags(flagSet, args)
+
+	ctx := context.Background()
+	driveService := authenticate(ctx)
+	client := newDriveClient(driveService, *apiRequestsPerSecond)
+	folderID, error := getDriveFolderIDByPath(driveService, *sourcePath)
+	if error != nil {
+		fatalf("ERRO: %v", error)
+	}
+
+	format := func(size int64) string { return fmt.Sprint(size) }
+	if *humanReadable {
+		format = humanSize
+	}
+	root := path.Clean("/" + *sourcePath)
+	if _, error := folderSize(ctx, client, folderID, root, format); error != nil {
+		fatalf("Não foi possível calcular o tamanho de '%s': %s", root, formatAPIError(error))
+	}
+}
+
+func folderSize(ctx context.Context, client *driveClient, folderID, folderPath string, format func(int64) string) (int64, error) {
+	var total int64
+	var pageToken string
+	for {
+		if error := client.wait(ctx); error != nil {
+			return 0, error
+		}
+		query := fmt.Sprintf("'%s' in parents and trashed=false", folderID)
+		fileList, error := listFiles(client.service).Q(query).PageSize(*pageSize).Fields("nextPageToken, files(id, name, mimeType, size)").PageToken(pageToken).Do()
+		client.done(error)
+		if error != nil {
+			return 0, error
+		}
+		for _, file := range fileList.Files {
+			if file.MimeType != "application/vnd.google-apps.folder" {
+				total += file.Size
+				continue
+			}
+			size, error := folderSize(ctx, client, file.Id, path.Join(folderPath, file.Name), format)
+			if error != nil {
+				return 0, error
+			}
+			total += size
+		}
+		pageToken = fileList.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	fmt.Printf("%s\t%s\n", format(total), folderPath)
+	return total, nil
+}
+
+func humanSize(size int64) string {
+	const units = "KMGTPE"
+	if size < 1024 {
+		return fmt.Sprint(size)
+	}
+	value := float64(size)
+	unit := -1
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f%c", value, units[unit])
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/md5"
+	"flag"
+	"os"
+
+	"google.golang.org/api/drive/v3"
+)
+
+const forceIfChangedUsage = "baixa novamente os arquivos existentes cujo MD5 é diferente do Drive; arquivos sem MD5 (documentos do Google) são sempre exportados de novo"
+
+var (
+	force          = flag.Bool("force", false, "baixa todos os arquivos novamente, mesmo os que já existem ou constam em --state-db; os existentes são renomeados para <arquivo>.bak")
+	noBackup       = flag.Bool("no-backup", false, "com --force, sobrescreve os arquivos existentes sem criar <arquivo>.bak")
+	forceIfChanged = flag.Bool("force-if-changed", false, forceIfChangedUsage)
+)
+
+func init() {
+	flag.BoolVar(forceIfChanged, "redownload-if-changed", false, forceIfChangedUsage)
+}
+
+func backupExistingFile(filePath string) {
+	if !*force || *noBackup {
+		return
+	}
+	if _, error := os.Stat(filePath); error != nil {
+		return
+	}
+	if error := os.Rename(filePath, filePath+".bak"); error != nil {
+		errorLog.Printf("backup '%s': %v", filePath, error)
+		abortOnDiskError(error)
+	}
+}
+
+func localFileChanged(file *drive.File, filePath string) bool {
+	if file.Md5Checksum == "" {
+		return true
+	}
+	sum, error := hashFile(filePath, md5.New())
+	return error != nil || sum != file.Md5Checksum
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
+)
+
+type initStep int
+
+const (
+	initStepProject initStep = iota
+	initStepEnableAPI
+	initStepConsentScreen
+	initStepCredentials
+	initStepAuthorize
+	initStepValidate
+	initStepDone
+)
+
+var scopeDescriptions = map[string]string{
+	"readonly":  "somente leitura",
+	"readwrite": "de leitura e escrita",
+	"metadata":  "somente aos metadados",
+}
+
+func runInit(args []string) {
+	parseFlags(newCommandFlagSet("init"), args)
+	ctx := context.Background()
+
+	var driveService *drive.Service
+	step := initStepProject
+	if _, error := os.Stat(credentialsFile); error == nil {
+		fmt.Printf("'%s' já existe, pulando a criação das credenciais.\n", credentialsFile)
+		step = initStepAuthorize
+	}
+	for step != initStepDone {
+		switch step {
+		case initStepProject:
+			fmt.Println("Passo 1: crie um projeto no Google Cloud (ou escolha um existente):")
+			fmt.Println("  https://console.cloud.google.com/projectcreate")
+			waitForEnter()
+			step = initStepEnableAPI
+		case initStepEnableAPI:
+			fmt.Println("Passo 2: ative a Google Drive API no projeto:")
+			fmt.Println("  https://console.cloud.google.com/apis/library/drive.googleapis.com")
+			waitForEnter()
+			step = initStepConsentScreen
+		case initStepConsentScreen:
+			fmt.Println("Passo 3: configure a tela de consentimento OAuth (tipo \"Externo\") e adicione seu e-mail como usuário de teste:")
+			fmt.Println("  https://console.cloud.google.com/apis/credentials/consent")
+			waitForEnter()
+			step = initStepCredentials
+		case initStepCredentials:
+			fmt.Println("Passo 4: crie um ID do cliente OAuth do tipo \"App para computador\", baixe o JSON e salve-o como:")
+			fmt.Printf("  %s\n", credentialsFile)
+			fmt.Println("  https://console.cloud.google.com/apis/credentials")
+			waitForEnter()
+			b, error := os.ReadFile(credentialsFile)
+			if error != nil {
+				fmt.Printf("✗ Não foi possível ler '%s': %v\n", credentialsFile, error)
+				continue
+			}
+			if _, error := google.ConfigFromJSON(b, drive.DriveReadonlyScope); error != nil {
+				fmt.Printf("✗ '%s' não é um arquivo de credenciais válido: %v\n", credentialsFile, error)
+				fmt.Println("  Confira se o ID do cliente é do tipo \"App para computador\" e baixe o JSON novamente.")
+				continue
+			}
+			step = initStepAuthorize
+		case initStepAuthorize:
+			fmt.Printf("Passo 5: autorize o acesso %s ao seu Drive.\n", scopeDescriptions[*oauthScope])
+			driveService = authenticate(ctx)
+			step = initStepValidate
+		case initStepValidate:
+			about, error := driveService.About.Get().Fields("user(emailAddress)").Do()
+			switch {
+			case error == nil:
+				fmt.Printf("✓ Tudo pronto! Conectado como %s.\n", about.User.EmailAddress)
+				step = initStepDone
+			case apiErrorReason(error) == "accessNotConfigured":
+				fmt.Printf("✗ A Google Drive API não está ativada no projeto: %v\n", error)
+				step = initStepEnableAPI
+			case apiErrorCode(error) == http.StatusUnauthorized:
+				fmt.Printf("✗ O token de acesso foi recusado, autorizando novamente: %v\n", error)
+				os.Remove(scopeTokenFile(requestedScopes()))
+				step = initStepAuthorize
+			default:
+				fmt.Printf("✗ Não foi possível acessar o Drive: %v\n", error)
+				fmt.Println("  Execute 'godrive doctor' para um diagnóstico detalhado.")
+				os.Exit(1)
+			}
+		}
+	}
+}
+
+func waitForEnter() {
+	fmt.Print("Pressione Enter para continuar...")
+	b := make([]byte, 1)
+	for {
+		if n, error := os.Stdin.Read(b); error != nil || (n == 1 && b[0] == '\n') {
+			break
+		}
+	}
+	fmt.Println()
+}
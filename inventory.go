@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"os"
+	"strconv"
+	"sync"
+)
+
+var inventoryCSV = flag.String("inventory-csv", "", "grava um inventário CSV com uma linha por arquivo encontrado no escaneamento, ex.: inventory.csv")
+
+const inventoryFlushRows = 1000
+
+type inventoryWriter struct {
+	mutex  sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+	rows   int
+}
+
+var inventory *inventoryWriter
+
+func openInventory(path string) *inventoryWriter {
+	file, error := os.Create(path)
+	if error != nil {
+		fatalf("Não foi possível criar o inventário '%s': %v", path, error)
+	}
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"file_id", "name", "local_path", "mime_type", "size_bytes", "modified_time", "md5_checksum"})
+	return &inventoryWriter{file: file, writer: writer}
+}
+
+func (inventory *inventoryWriter) add(job *fileJob) {
+	if inventory == nil {
+		return
+	}
+	inventory.mutex.Lock()
+	defer inventory.mutex.Unlock()
+	file := job.file
+	inventory.writer.Write([]string{file.Id, file.Name, job.localPath, file.MimeType, strconv.FormatInt(file.Size, 10), file.ModifiedTime, file.Md5Checksum})
+	inventory.rows++
+	if inventory.rows%inventoryFlushRows == 0 {
+		inventory.flushLocked()
+	}
+}
+
+func (inventory *inventoryWriter) flush() {
+	if inventory == nil {
+		return
+	}
+	inventory.mutex.Lock()
+	defer inventory.mutex.Unlock()
+	inventory.flushLocked()
+}
+
+func (inventory *inventoryWriter) flushLocked() {
+	inventory.writer.Flush()
+	if error := inventory.writer.Error(); error != nil {
+		errorLog.Printf("write inventory '%s': %v", inventory.file.Name(), error)
+	}
+}
+
+func (inventory *inventoryWriter) close() {
+	if inventory == nil {
+		return
+	}
+	inventory.flush()
+	inventory.file.Close()
+}
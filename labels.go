@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/drivelabels/v2"
+	"google.golang.org/api/option"
+)
+
+var exportLabels = flag.Bool("export-labels", false, "salva os rótulos do Drive (Drive Labels) aplicados a cada arquivo baixado em <arquivo>.labels.json")
+
+var (
+	labelsService     *drivelabels.Service
+	labelSchemas      = map[string]*labelSchemaEntry{}
+	labelSchemasMutex sync.Mutex
+)
+
+type labelSchemaEntry struct {
+	mutex  sync.Mutex
+	schema *drivelabels.GoogleAppsDriveLabelsV2Label
+}
+
+type appliedLabel struct {
+	ID     string              `json:"id"`
+	Title  string              `json:"title,omitempty"`
+	Fields []appliedLabelField `json:"fields"`
+}
+
+type appliedLabelField struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name,omitempty"`
+	Type   string   `json:"type"`
+	Values []string `json:"values"`
+}
+
+func newLabelsService(ctx context.Context, client *http.Client) *drivelabels.Service {
+	service, error := drivelabels.NewService(ctx, option.WithHTTPClient(client))
+	if error != nil {
+		fatalf("Não foi possível criar o serviço da Drive Labels API: %v", error)
+	}
+	return service
+}
+
+func exportFileLabels(ctx context.Context, client *driveClient, file *drive.File, filePath string) {
+	var labels []*drive.Label
+	var pageToken string
+	for {
+		if error := waitSidecar(ctx, client); error != nil {
+			errorLog.Printf("list labels of '%s': %s", file.Name, formatAPIError(error))
+			return
+		}
+		labelList, error := client.service.Files.ListLabels(file.Id).PageToken(pageToken).Context(ctx).Do()
+		client.done(error)
+		if error != nil {
+			errorLog.Printf("list labels of '%s': %s", file.Name, formatAPIError(error))
+			return
+		}
+		labels = append(labels, labelList.Labels...)
+		pageToken = labelList.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	if len(labels) == 0 {
+		return
+	}
+
+	applied := make([]appliedLabel, 0, len(labels))
+	for _, label := range labels {
+		applied = append(applied, describeLabel(ctx, client, label))
+	}
+	writeSidecar(filePath+".labels.json", applied)
+}
+
+func describeLabel(ctx context.Context, client *driveClient, label *drive.Label) appliedLabel {
+	schema := labelSchema(ctx, client, label.Id)
+	applied := appliedLabel{ID: label.Id, Fields: []appliedLabelField{}}
+	fieldNames := map[string]string{}
+	choiceNames := map[string]string{}
+	if schema != nil {
+		if schema.Properties != nil {
+			applied.Title = schema.Properties.Title
+		}
+		for _, field := range schema.Fields {
+			if field.Properties != nil {
+				fieldNames[field.Id] = field.Properties.DisplayName
+			}
+			if field.SelectionOptions == nil {
+				continue
+			}
+			for _, choice := range field.SelectionOptions.Choices {
+				if choice.Properties != nil {
+					choiceNames[choice.Id] = choice.Properties.DisplayName
+				}
+			}
+		}
+	}
+
+	for id, field := range label.Fields {
+		values := append([]string{}, field.Text...)
+		values = append(values, field.DateString...)
+		for _, integer := range field.Integer {
+			values = append(values, strconv.FormatInt(integer, 10))
+		}
+		for _, choice := range field.Selection {
+			if name := choiceNames[choice]; name != "" {
+				choice = name
+			}
+			values = append(values, choice)
+		}
+		for _, user := range field.User {
+			values = append(values, user.EmailAddress)
+		}
+		applied.Fields = append(applied.Fields, appliedLabelField{ID: id, Name: fieldNames[id], Type: field.ValueType, Values: values})
+	}
+	sort.Slice(applied.Fields, func(i, j int) bool { return applied.Fields[i].ID < applied.Fields[j].ID })
+	return applied
+}
+
+func labelSchema(ctx context.Context, client *driveClient, labelID string) *drivelabels.GoogleAppsDriveLabelsV2Label {
+	labelSchemasMutex.Lock()
+	entry, ok := labelSchemas[labelID]
+	if !ok {
+		entry = &labelSchemaEntry{}
+		labelSchemas[labelID] = entry
+	}
+	labelSchemasMutex.Unlock()
+
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+	if entry.schema != nil {
+		return entry.schema
+	}
+	if error := waitSidecar(ctx, client); error != nil {
+		return nil
+	}
+	schema, error := labelsService.Labels.Get("labels/" + labelID).View("LABEL_VIEW_FULL").Context(ctx).Do()
+	client.done(error)
+	if error != nil {
+		errorLog.Printf("get label '%s': %s", labelID, formatAPIError(error))
+		return nil
+	}
+	entry.schema = schema
+	return schema
+}
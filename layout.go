@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	flat             = flag.Bool("flat", false, "salva todos os arquivos diretamente em --dest, sem recriar as pastas do Drive")
+	pathTemplateText = flag.String("path-template", "", "modelo (text/template) do caminho de cada arquivo dentro de --dest, ex.: '{{.ModifiedTime.Year}}/{{.ModifiedTime.Month}}/{{.Name}}'")
+	organizeByType   = flag.Bool("organize-by-type", false, "separa os arquivos em subpastas de --dest por tipo (images, videos, documents, ...)")
+	pathTemplateTest = flag.Bool("path-template-test", false, "mostra alguns exemplos de caminhos gerados por --path-template e sai sem baixar nada")
+	originalFilename = flag.Bool("use-original-filename", false, "usa o nome original do arquivo (originalFilename, ex.: fotos da câmera) em vez do nome exibido no Drive")
+)
+
+const pathTemplateSamples = 10
+
+var pathTemplate *template.Template
+
+type pathTemplateData struct {
+	*drive.File
+	CreatedTime  time.Time
+	ModifiedTime time.Time
+}
+
+var (
+	flatNamesMutex sync.Mutex
+	flatNames      = map[string]string{}
+)
+
+func fileFields() googleapi.Field {
+	if *originalFilename {
+		return driveFileFields + ", originalFilename"
+	}
+	return driveFileFields
+}
+
+func useOriginalFilename(file *drive.File) {
+	if *originalFilename && file.OriginalFilename != "" {
+		file.Name = file.OriginalFilename
+	}
+}
+
+func mirrorsFolders() bool {
+	return !*flat && !*organizeByType && pathTemplate == nil
+}
+
+func fileLocalPath(file *drive.File, parentID, rootPath, folderLocalPath string) string {
+	if pathTemplate != nil {
+		relativePath, error := renderPathTemplate(file)
+		if error == nil {
+			return filepath.Join(rootPath, relativePath)
+		}
+		errorLog.Printf("render path template for '%s': %v", file.Name, error)
+	}
+	name := sanitizeFileName(file.Name)
+	if *flat {
+		name = flatFileName(file.Id, parentID, name)
+		folderLocalPath = rootPath
+	}
+	if *organizeByType {
+		relativeFolder, error := filepath.Rel(rootPath, folderLocalPath)
+		if error != nil {
+			relativeFolder = ""
+		}
+		folderLocalPath = filepath.Join(rootPath, mimeCategory(file.MimeType), relativeFolder)
+	}
+	return filepath.Join(folderLocalPath, name)
+}
+
+func mimeCategory(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"), mimeType == "application/vnd.google-apps.drawing", mimeType == "application/vnd.google-apps.photo":
+		return "images"
+	case strings.HasPrefix(mimeType, "video/"), mimeType == "application/vnd.google-apps.video":
+		return "videos"
+	case strings.HasPrefix(mimeType, "audio/"), mimeType == "application/vnd.google-apps.audio":
+		return "audio"
+	}
+	switch mimeType {
+	case "application/vnd.google-apps.document", "application/pdf", "application/msword", "application/rtf", "text/plain", "text/markdown",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/vnd.oasis.opendocument.text":
+		return "documents"
+	case "application/vnd.google-apps.spreadsheet", "application/vnd.ms-excel", "text/csv",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/vnd.oasis.opendocument.spreadsheet":
+		return "spreadsheets"
+	case "application/vnd.google-apps.presentation", "application/vnd.ms-powerpoint",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation", "application/vnd.oasis.opendocument.presentation":
+		return "presentations"
+	case "application/zip", "application/x-zip-compressed", "application/x-tar", "application/gzip", "application/x-gzip",
+		"application/x-7z-compressed", "application/x-rar-compressed", "application/vnd.rar":
+		return "archives"
+	}
+	return "other"
+}
+
+type flatPendingFile struct {
+	file     *drive.File
+	parentID string
+}
+
+func flatFileName(fileID, parentID, name string) string {
+	flatNamesMutex.Lock()
+	defer flatNamesMutex.Unlock()
+	for _, candidate := range []string{name, parentID + "_" + name} {
+		if owner, ok := flatNames[candidate]; !ok || owner == fileID {
+			flatNames[candidate] = fileID
+			return candidate
+		}
+	}
+	return fileID + "_" + name
+}
+
+func parsePathTemplate() {
+	if *pathTemplateText == "" {
+		if *pathTemplateTest {
+			fatal("--path-template-test exige --path-template")
+		}
+		return
+	}
+	parsed, error := template.New("path").Parse(*pathTemplateText)
+	if error != nil {
+		fatalf("Modelo de caminho inválido: %v", error)
+	}
+	pathTemplate = parsed
+}
+
+func renderPathTemplate(file *drive.File) (string, error) {
+	data := pathTemplateData{File: file}
+	data.CreatedTime, _ = time.Parse(time.RFC3339, file.CreatedTime)
+	data.ModifiedTime, _ = time.Parse(time.RFC3339, file.ModifiedTime)
+	var rendered strings.Builder
+	if error := pathTemplate.Execute(&rendered, data); error != nil {
+		return "", error
+	}
+	var segments []string
+	for _, segment := range strings.Split(filepath.ToSlash(rendered.String()), "/") {
+		if segment != "" && segment != "." && segment != ".." {
+			segments = append(segments, sanitizeFileName(segment))
+		}
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("o modelo gerou um caminho vazio")
+	}
+	return filepath.Join(segments...), nil
+}
+
+func printPathTemplateSamples(ctx context.Context, client *driveClient, folderID string) {
+	folders := []string{folderID}
+	samples := 0
+	for len(folders) > 0 && samples < pathTemplateSamples {
+		currentFolderID := folders[0]
+		folders = folders[1:]
+		if error := client.wait(ctx); error != nil {
+			fatal(error)
+		}
+		query := fmt.Sprintf("'%s' in parents and trashed=false", currentFolderID)
+		fileList, error := listFiles(client.service).Q(query).PageSize(100).Fields("files(" + fileFields() + ")").Do()
+		client.done(error)
+		if error != nil {
+			fatalf("Não foi possível listar a pasta ID '%s': %v", currentFolderID, error)
+		}
+		for _, file := range fileList.Files {
+			if file.MimeType == "application/vnd.google-apps.folder" {
+				folders = append(folders, file.Id)
+				continue
+			}
+			useOriginalFilename(file)
+			if samples == pathTemplateSamples {
+				break
+			}
+			relativePath, error := renderPathTemplate(file)
+			if error != nil {
+				relativePath = "ERRO: " + error.Error()
+			}
+			fmt.Printf("%s -> %s\n", file.Name, relativePath)
+			samples++
+		}
+	}
+	if samples == 0 {
+		fmt.Println("Nenhum arquivo encontrado para gerar exemplos.")
+	}
+}
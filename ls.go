@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+func runLs(args []string) {
+	flagSet := newCommandFlagSet("ls")
+	long := flagSet.Bool("long", false, "mostra tipo, tamanho, data de modificação e dono de cada item, e o total de itens de cada pasta")
+	parseFlags(flagSet, args)
+
+	ctx := context.Background()
+	driveService := authenticate(ctx)
+	client := newDriveClient(driveService, *apiRequestsPerSecond)
+	folderID, error := getDriveFolderIDByPath(driveService, *sourcePath)
+	if error != nil {
+		fatalf("ERRO: %v", error)
+	}
+
+	files, error := listChildren(ctx, client, folderID, "nextPageToken, files(id, name, mimeType, size, modifiedTime, owners(displayName, emailAddress))")
+	if error != nil {
+		fatalf("Não foi possível listar '%s': %s", *sourcePath, formatAPIError(error))
+	}
+	if !*long {
+		for _, file := range files {
+			if file.MimeType == "application/vnd.google-apps.folder" {
+				fmt.Println(file.Name + "/")
+			} else {
+				fmt.Println(file.Name)
+			}
+		}
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NOME\tTIPO\tTAMANHO\tMODIFICADO\tDONO")
+	for _, file := range files {
+		kind, size := "arquivo", "—"
+		if file.MimeType == "application/vnd.google-apps.folder" {
+			count, error := countFolderItems(ctx, client, file.Id)
+			if error != nil {
+				errorLog.Printf("count items of '%s': %s", file.Name, formatAPIError(error))
+				kind = "pasta (?)"
+			} else {
+				kind = fmt.Sprintf("pasta (%d itens)", count)
+			}
+		} else if file.Size > 0 {
+			size = humanSize(file.Size)
+		}
+		modified := file.ModifiedTime
+		if modifiedTime, error := time.Parse(time.RFC3339, file.ModifiedTime); error == nil {
+			modified = modifiedTime.Local().Format("2006-01-02 15:04")
+		}
+		owner := ""
+		if len(file.Owners) > 0 {
+			owner = file.Owners[0].EmailAddress
+			if owner == "" {
+				owner = file.Owners[0].DisplayName
+			}
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", file.Name, kind, size, modified, owner)
+	}
+	writer.Flush()
+}
+
+func listChildren(ctx context.Context, client *driveClient, folderID string, fields googleapi.Field) ([]*drive.File, error) {
+	var files []*drive.File
+	query := fmt.Sprintf("'%s' in parents and trashed=false", folderID)
+	var pageToken string
+	for {
+		if error := client.wait(ctx); error != nil {
+			return nil, error
+		}
+		fileList, error := listFiles(client.service).Q(query).PageSize(*pageSize).Fields(fields).OrderBy("folder, name").PageToken(pageToken).Do()
+		client.done(error)
+		if error != nil {
+			return nil, error
+		}
+		files = append(files, fileList.Files...)
+		pageToken = fileList.NextPageToken
+		if pageToken == "" {
+			return files, nil
+		}
+	}
+}
+
+func countFolderItems(ctx context.Context, client *driveClient, folderID string) (int, error) {
+	children, error := listChildren(ctx, client, folderID, "nextPageToken, files(id, mimeType)")
+	if error != nil {
+		return 0, error
+	}
+	count := len(children)
+	for _, child := range children {
+		if child.MimeType != "application/vnd.google-apps.folder" {
+			continue
+		}
+		nested, error := countFolderItems(ctx, client, child.Id)
+		if error != nil {
+			return 0, error
+		}
+		count += nested
+	}
+	return count, nil
+}
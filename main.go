@@ -2,101 +2,425 @@ package main
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/drivelabels/v2"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
 const (
-	numWorkers      = 1000
 	downloadPath    = "/media/ghs/hd/godrive2/"
 	driveFolderPath = "drive"
+	deadLetterFile  = "dead_letter.json"
+
+	maxTokenFileSize = 64 * 1024
+	fileJobQueueSize = 200000
+)
+
+var (
+	tokenFile       = "token.json"
+	credentialsFile = "credentials.json"
+)
+
+var (
+	sourcePath        = flag.String("src", driveFolderPath, "caminho ou link (https://drive.google.com/drive/folders/...) da pasta no Drive a ser baixada (vazio ou \"root\" para o Drive inteiro)")
+	destinationPath   = flag.String("dest", downloadPath, "diretório local de destino dos arquivos")
+	skipForms         = flag.Bool("skip-forms", false, "não exporta formulários do Google (Forms) como PDF")
+	skipMaps          = flag.Bool("skip-maps", false, "não exporta mapas do Google My Maps como KML")
+	skipSites         = flag.Bool("skip-sites", false, "não exporta sites do Google Sites como ZIP")
+	drawingFormat     = flag.String("drawing-format", "svg", "formato de exportação dos desenhos do Google: svg, png, jpeg ou pdf")
+	corpora           = flag.String("corpora", "user", "conjunto de arquivos listados: user, domain ou allDrives (inclui os drives compartilhados)")
+	starred           = flag.Bool("starred", false, "baixa apenas os arquivos marcados com estrela")
+	trashed           = flag.Bool("trashed", false, "inclui os arquivos da lixeira, salvos em _trash/ dentro do diretório de destino")
+	trashedOnly       = flag.Bool("trashed-only", false, "baixa apenas os arquivos da lixeira, salvos em _trash/ dentro do diretório de destino")
+	htmlReport        = flag.Bool("html-report", false, "ao final, gera um relatório godrive_report_<data>.html com os arquivos pulados e com falha")
+	maxRetries        = flag.Int("max-retries", 3, "quantidade de novas tentativas para cada arquivo que falhar antes de registrá-lo em "+deadLetterFile)
+	splitLargeExports = flag.Bool("split-large-exports", false, "exporta como PDF os documentos do Google grandes demais para o formato padrão")
+	discoveryWorkers  = flag.Int("discovery-workers", 10, "quantidade máxima de listagens de pastas simultâneas durante o escaneamento")
+	pageSize          = flag.Int64("page-size", 1000, "quantidade de itens pedidos por página nas listagens da API do Drive (máximo 1000)")
+	apiEndpoint       = flag.String("api-endpoint", "", "endereço alternativo da API do Drive, ex.: http://localhost:8080 para testes com um servidor simulado")
+	oauthScope        = flag.String("scope", "readonly", "permissão pedida ao Google: readonly (padrão), readwrite ou metadata; cada escopo usa um token próprio e exige uma nova autorização")
+	docker            = flag.Bool("docker", false, "não cria skipped.log, restricted.log e error.log: registra os arquivos pulados e restritos na saída padrão e os erros na saída de erro")
+)
+
+var drawingExportFormats = map[string][2]string{
+	"svg":  {"image/svg+xml", ".svg"},
+	"png":  {"image/png", ".png"},
+	"jpeg": {"image/jpeg", ".jpg"},
+	"pdf":  {"application/pdf", ".pdf"},
+}
+
+var (
+	skippedLog    *log.Logger
+	errorLog      *log.Logger
+	restrictedLog *log.Logger
 )
 
 var (
-	skippedLog *log.Logger
-	errorLog   *log.Logger
+	cancelRun     context.CancelFunc
+	diskError     error
+	diskErrorOnce sync.Once
 )
 
+const apiVersion = "v3"
+
+const driveFileFields = "id, name, mimeType, resourceKey, md5Checksum, size, createdTime, modifiedTime"
+
 type fileJob struct {
-	file      *drive.File
-	localPath string
+	file            *drive.File
+	localPath       string
+	replaceExisting bool
 }
 
 type statusTracker struct {
 	totalFilesFound     atomic.Int32
 	completedFiles      atomic.Int32
 	skippedFiles        atomic.Int32
+	accessDenied        atomic.Int32
+	restrictedFiles     atomic.Int32
+	failedFiles         atomic.Int32
 	isDiscoveryFinished atomic.Bool
 	startTime           time.Time
+	channelFileJob      chan *fileJob
+	reportMutex         sync.Mutex
+	skippedEntries      []reportEntry
+	failedEntries       []reportEntry
 }
 
 func init() {
+	skippedLog = log.New(os.Stdout, "SKIPPED ", log.Ldate|log.Ltime|log.Lshortfile)
+	errorLog = log.New(os.Stderr, "ERROR ", log.Ldate|log.Ltime|log.Lshortfile)
+	restrictedLog = log.New(os.Stdout, "RESTRICTED ", log.Ldate|log.Ltime|log.Lshortfile)
+}
+
+const levelFatal = slog.LevelError + 4
+
+func newDockerHandler(output io.Writer, name string) slog.Handler {
+	handler := slog.NewJSONHandler(output, &slog.HandlerOptions{
+		AddSource: true,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.LevelKey && attr.Value.Any() == levelFatal {
+				attr.Value = slog.StringValue("FATAL")
+			}
+			return attr
+		},
+	})
+	return handler.WithAttrs([]slog.Attr{slog.String("log", name)})
+}
+
+func openLogFiles() {
+	if *docker {
+		slog.SetDefault(slog.New(newDockerHandler(os.Stderr, "godrive")))
+		skippedLog = slog.NewLogLogger(newDockerHandler(os.Stdout, "skipped"), slog.LevelInfo)
+		errorLog = slog.NewLogLogger(newDockerHandler(os.Stderr, "error"), slog.LevelError)
+		restrictedLog = slog.NewLogLogger(newDockerHandler(os.Stdout, "restricted"), slog.LevelInfo)
+		return
+	}
+
 	skippedFile, err := os.OpenFile("skipped.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 	if err != nil {
-		log.Fatal("Failed to open info log file:", err)
+		fatal("Failed to open info log file:", err)
 	}
-
-	skippedLog = log.New(skippedFile, "", log.Ldate|log.Ltime|log.Lshortfile)
+	skippedLog.SetOutput(skippedFile)
+	skippedLog.SetPrefix("")
 
 	errorFile, err := os.OpenFile("error.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 	if err != nil {
-		log.Fatal("Failed to open error log file:", err)
+		fatal("Failed to open error log file:", err)
+	}
+	errorLog.SetOutput(errorFile)
+	errorLog.SetPrefix("")
+
+	restrictedFile, err := os.OpenFile("restricted.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		fatal("Failed to open restricted log file:", err)
 	}
-	errorLog = log.New(errorFile, "", log.Ldate|log.Ltime|log.Lshortfile)
+	restrictedLog.SetOutput(restrictedFile)
+	restrictedLog.SetPrefix("")
+}
+
+func fatalf(format string, args ...any) {
+	exitFatal(fmt.Sprintf(format, args...))
+}
+
+func fatal(args ...any) {
+	exitFatal(fmt.Sprint(args...))
+}
+
+func exitFatal(message string) {
+	if !*docker {
+		log.Output(3, message)
+		os.Exit(1)
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), levelFatal, message, pcs[0])
+	slog.Default().Handler().Handle(context.Background(), record)
+	os.Exit(1)
+}
+
+var commands = map[string]func(args []string){
+	"doctor":            runDoctor,
+	"quota":             runQuota,
+	"search":            runSearch,
+	"retry-dead-letter": runRetryDeadLetter,
+	"verify":            runVerify,
+	"profile":           runProfile,
+	"init":              runInit,
+	"upload":            runUpload,
+	"rm":                runRm,
+	"cp":                runCp,
+	"du":                runDu,
+	"mv":                runMv,
+	"state":             runState,
+	"ls":                runLs,
+	"version":           runVersion,
 }
 
 func main() {
-	context := context.Background()
-	driveService := authenticate(context)
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
 
-	fmt.Printf("Resolvendo o caminho da pasta do Drive: '%s'\n", driveFolderPath)
-	folderID, error := getDriveFolderIDByPath(driveService, driveFolderPath)
+	if workers, error := strconv.Atoi(os.Getenv("GODRIVE_WORKERS")); error == nil && workers > 0 {
+		*maxWorkers = workers
+		*minWorkers = min(*minWorkers, workers)
+	}
+	parseFlags(flag.CommandLine, os.Args[1:])
+	if *accountsFile != "" {
+		runAccounts()
+		return
+	}
+	if _, ok := drawingExportFormats[*drawingFormat]; !ok {
+		fatalf("Formato de desenho inválido: '%s' (use svg, png, jpeg ou pdf)", *drawingFormat)
+	}
+	if *discoveryWorkers < 1 {
+		fatalf("--discovery-workers deve ser pelo menos 1")
+	}
+	switch *conflictStrategy {
+	case "overwrite", "skip", "rename", "ask":
+	default:
+		fatalf("Valor inválido para --conflict: '%s' (use overwrite, skip, rename ou ask)", *conflictStrategy)
+	}
+	if strategy, ok := jitterStrategies[*backoffJitter]; ok {
+		retryJitter = strategy
+	} else {
+		fatalf("Valor inválido para --backoff-jitter: '%s' (use full, equal, decorrelated ou none)", *backoffJitter)
+	}
+	if *pageSize < 1 || *pageSize > 1000 {
+		fatalf("--page-size deve estar entre 1 e 1000")
+	}
+	if endpoint, error := url.Parse(*apiEndpoint); *apiEndpoint != "" && (error != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "") {
+		fatalf("Valor inválido para --api-endpoint: '%s' (use uma URL http:// ou https://)", *apiEndpoint)
+	}
+	if *trashed && *trashedOnly {
+		fatalf("Use apenas um entre --trashed e --trashed-only")
+	}
+	if *checkpointEvery < 0 {
+		fatalf("--checkpoint-every não pode ser negativo")
+	}
+	validateDestination()
+	validateNotifications()
+	if *minWorkers < 1 {
+		fatalf("--min-workers deve ser pelo menos 1")
+	}
+	if *minWorkers > *maxWorkers {
+		fatalf("--min-workers (%d) não pode ser maior que --max-workers (%d)", *minWorkers, *maxWorkers)
+	}
+	if *corpora != "user" && *corpora != "domain" && *corpora != "allDrives" {
+		fatalf("Valor inválido para --corpora: '%s' (use user, domain ou allDrives)", *corpora)
+	}
+	if *hashAlgorithm != "" && newManifestHash() == nil {
+		fatalf("Valor inválido para --hash: '%s' (use sha256 ou blake3)", *hashAlgorithm)
+	}
+	if *allSharedDrives {
+		*corpora = "allDrives"
+	}
+	parsePathTemplate()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelRun = cancel
+
+	if *probeAddr != "" {
+		startProbeServer()
+	}
+
+	driveService := authenticate(ctx)
+	probes.ready.Store(true)
+	client := newDriveClient(driveService, *apiRequestsPerSecond)
+	deadLetters = loadDeadLetterQueue(deadLetterFile)
+
+	fmt.Printf("Resolvendo o caminho da pasta do Drive: '%s'\n", *sourcePath)
+	folderID, error := getDriveFolderIDByPath(driveService, *sourcePath)
 	if error != nil {
-		log.Println("ERRO: %v", error)
+		log.Printf("ERRO: %v", error)
 	}
 
-	channelFileJob := make(chan *fileJob, 200000)
-	var downloadWaitGroup sync.WaitGroup
-	var discoveryWaitGroup sync.WaitGroup
+	if *computers {
+		if computersFolderID := resolveComputersFolder(driveService); computersFolderID != "" {
+			extraSyncRoots = append(extraSyncRoots, syncRoot{folderID: computersFolderID, relativePath: "Computers"})
+		}
+	}
+	if *allSharedDrives {
+		extraSyncRoots = append(extraSyncRoots, listSharedDriveRoots(driveService)...)
+	}
 
-	statusTracker := statusTracker{startTime: time.Now()}
+	var pageToken string
+	if *incrementalChanges || *pushNotifications {
+		startPageToken, error := driveService.Changes.GetStartPageToken().SupportsAllDrives(true).Do()
+		if error != nil {
+			fatalf("Não foi possível obter o token inicial de alterações: %v", error)
+		}
+		pageToken = startPageToken.StartPageToken
+	}
 
-	channelIsDone := make(chan bool)
-	go printStatus(&statusTracker, channelIsDone)
+	if *pathTemplateTest {
+		printPathTemplateSamples(ctx, client, folderID)
+		return
+	}
 
-	for workerID := 1; workerID <= numWorkers; workerID++ {
-		go startDownloadWorker(workerID, driveService, channelFileJob, &downloadWaitGroup, &statusTracker)
+	snapshotRoot := *destinationPath
+	if *snapshot {
+		*destinationPath = filepath.Join(snapshotRoot, time.Now().UTC().Format(time.RFC3339))
+		fmt.Printf("Salvando snapshot em: %s\n", *destinationPath)
 	}
 
+	if *inventoryCSV != "" {
+		inventory = openInventory(*inventoryCSV)
+		defer inventory.close()
+	}
+
+	if *stateDBPath != "" {
+		stateDB = openStateDB(*stateDBPath)
+		defer stateDB.Close()
+	}
+
+	if *checkpointEvery > 0 || *resume {
+		checkpoint = openCheckpoint(checkpointFile, *checkpointEvery, *resume)
+	}
+
+	openDestination()
+
 	fmt.Println("Iniciando escaneamento e download simultaneamente...")
-	discoveryWaitGroup.Add(1)
-	go discoverAndQueueFiles(driveService, folderID, downloadPath, channelFileJob, &downloadWaitGroup, &discoveryWaitGroup, &statusTracker)
+	syncFolder(ctx, client, folderID)
+	closeDestination()
+
+	if *snapshot && *maxSnapshots > 0 {
+		pruneSnapshots(snapshotRoot, *maxSnapshots)
+	}
+
+	if *watch && *pushNotifications {
+		watchPushNotifications(ctx, client, folderID, pageToken)
+	} else if *watch {
+		watchForChanges(ctx, client, folderID, pageToken)
+	}
+}
+
+func syncFolder(ctx context.Context, client *driveClient, folderID string) {
+	if *structureJSON {
+		structure = newStructureRecorder()
+	}
+	runSync(ctx, client, func(channelFileJob chan<- *fileJob, statusTracker *statusTracker) {
+		var discoveryWaitGroup sync.WaitGroup
+		discoveryWaitGroup.Add(1)
+		go discoverAndQueueFiles(ctx, client, folderID, *destinationPath, channelFileJob, &discoveryWaitGroup, statusTracker)
+		for _, root := range extraSyncRoots {
+			discoveryWaitGroup.Add(1)
+			go discoverAndQueueFiles(ctx, client, root.folderID, filepath.Join(*destinationPath, root.relativePath), channelFileJob, &discoveryWaitGroup, statusTracker)
+		}
+		discoveryWaitGroup.Wait()
+		structure.write(structureFile)
+	})
+}
+
+func runSync(ctx context.Context, client *driveClient, queueFiles func(chan<- *fileJob, *statusTracker)) {
+	channelFileJob := make(chan *fileJob, fileJobQueueSize)
+
+	statusTracker := statusTracker{startTime: time.Now(), channelFileJob: channelFileJob}
 
-	discoveryWaitGroup.Wait()
+	channelIsDone := make(chan bool)
+	go printStatus(&statusTracker, channelIsDone)
+
+	pool := newWorkerPool(channelFileJob, *minWorkers, *maxWorkers, func(job *fileJob, heartbeat *workerHeartbeat) {
+		handleFileJob(ctx, client, job, &statusTracker, heartbeat)
+	})
+	pool.start()
+
+	queueFiles(channelFileJob, &statusTracker)
+	inventory.flush()
 	statusTracker.isDiscoveryFinished.Store(true)
 
 	close(channelFileJob)
 
-	downloadWaitGroup.Wait()
+	pool.wait()
+	checkpoint.save()
+	if *nomedia {
+		writeNomediaFiles()
+	}
 
 	channelIsDone <- true
 
 	fmt.Println()
+
+	if *workerStats {
+		pool.printStats()
+	}
+
+	if *htmlReport {
+		writeHTMLReport(&statusTracker)
+	}
+
+	clean := statusTracker.failedFiles.Load() == 0 && diskError == nil
+	finishProbeRun(clean)
+	sendNotification(&statusTracker, clean)
+	sendDiscordNotification(&statusTracker, clean)
+	if stateDB != nil && *clearState && clean && ctx.Err() == nil {
+		clearStateDB()
+	}
+
+	if diskError != nil {
+		fatalf("Downloads interrompidos por erro de disco: %v", diskError)
+	}
+}
+
+func newCommandFlagSet(name string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet("godrive "+name, flag.ExitOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		flagSet.Var(f.Value, f.Name, f.Usage)
+	})
+	return flagSet
+}
+
+func (statusTracker *statusTracker) channelFileJobLen() int {
+	return len(statusTracker.channelFileJob)
 }
 
 func printStatus(statusTracker *statusTracker, done chan bool) {
@@ -105,13 +429,18 @@ func printStatus(statusTracker *statusTracker, done chan bool) {
 		case <-done:
 			total := statusTracker.totalFilesFound.Load()
 			skipped := statusTracker.skippedFiles.Load()
-			finalLine := fmt.Sprintf("\rProgresso: %d / %d concluídos (Pulados: %d) - Finalizado!                \n", total, total, skipped)
+			denied := statusTracker.accessDenied.Load()
+			restricted := statusTracker.restrictedFiles.Load()
+			failed := statusTracker.failedFiles.Load()
+			finalLine := fmt.Sprintf("\rProgresso: %d / %d concluídos (Pulados: %d, Acesso negado: %d, Restritos: %d, Falhas: %d) - Finalizado!                \n", total, total, skipped, denied, restricted, failed)
 			fmt.Print(finalLine)
 			return
 		default:
 			completed := statusTracker.completedFiles.Load()
 			totalFound := statusTracker.totalFilesFound.Load()
 			skipped := statusTracker.skippedFiles.Load()
+			denied := statusTracker.accessDenied.Load()
+			restricted := statusTracker.restrictedFiles.Load()
 
 			percentage := float64(0)
 			if totalFound > 0 {
@@ -121,7 +450,7 @@ func printStatus(statusTracker *statusTracker, done chan bool) {
 			etaStr := "--:--:--"
 			elapsedSeconds := time.Since(statusTracker.startTime).Seconds()
 
-			actualDownloads := completed - skipped
+			actualDownloads := completed - skipped - denied - restricted
 
 			if actualDownloads > 5 && elapsedSeconds > 3 {
 				rate := float64(actualDownloads) / elapsedSeconds
@@ -142,7 +471,13 @@ func printStatus(statusTracker *statusTracker, done chan bool) {
 				discoveryStatus = "(Escaneando...)"
 			}
 
-			statusLine := fmt.Sprintf("\rProgresso: %d/%d (%.2f%%) | Pulados: %d %s| ETA: %s  ", completed, totalFound, percentage, skipped, discoveryStatus, etaStr)
+			queued := statusTracker.channelFileJobLen()
+			queueStatus := ""
+			if capacity := cap(statusTracker.channelFileJob); capacity > 0 && queued >= capacity*9/10 {
+				queueStatus = " (cheia)"
+			}
+
+			statusLine := fmt.Sprintf("\rProgresso: %d/%d (%.2f%%) | Pulados: %d | Acesso negado: %d | Restritos: %d %s| Fila: %d%s | ETA: %s  ", completed, totalFound, percentage, skipped, denied, restricted, discoveryStatus, queued, queueStatus, etaStr)
 			fmt.Print(statusLine)
 
 			time.Sleep(200 * time.Millisecond)
@@ -150,126 +485,413 @@ func printStatus(statusTracker *statusTracker, done chan bool) {
 	}
 }
 
-func startDownloadWorker(workerID int, driverService *drive.Service, channelFileJob <-chan *fileJob, waitGroup *sync.WaitGroup, statusTracker *statusTracker) {
-	defer waitGroup.Done()
-	for fileJob := range channelFileJob {
-		if strings.HasPrefix(fileJob.file.MimeType, "application/vnd.google-apps") {
-			convertGoogleFileType(driverService, fileJob.file, fileJob.localPath, statusTracker)
-		} else {
-			downloadFile(driverService, fileJob.file, fileJob.localPath, statusTracker)
-		}
+func handleFileJob(ctx context.Context, client *driveClient, fileJob *fileJob, statusTracker *statusTracker, heartbeat *workerHeartbeat) {
+	if ctx.Err() != nil {
+		return
+	}
+	if stateCompleted(fileJob) || checkpoint.done(fileJob) {
+		statusTracker.skip(fileJob, "já baixado em uma execução anterior")
 		statusTracker.completedFiles.Add(1)
+		return
+	}
+	var delay time.Duration
+	for attempt := 0; ; attempt++ {
+		attemptCtx, done := heartbeat.begin(ctx)
+		written, error := processFileJob(attemptCtx, client, fileJob, statusTracker)
+		done()
+		if error == nil {
+			deadLetters.remove(fileJob.file.Id)
+			if written {
+				heartbeat.stats.filesDownloaded.Add(1)
+				markStateCompleted(fileJob)
+				checkpoint.mark(fileJob)
+			}
+			break
+		}
+		if errors.Is(error, errCircuitOpen) && ctx.Err() == nil {
+			attempt--
+			select {
+			case <-ctx.Done():
+			case <-time.After(client.breaker.retryAfter()):
+			}
+			continue
+		}
+		heartbeat.stats.errors.Add(1)
+		if ctx.Err() != nil {
+			break
+		}
+		if attempt >= *maxRetries {
+			statusTracker.fail(fileJob, error)
+			deadLetters.add(fileJob, error)
+			recordState(failedBucket, fileJob, error.Error())
+			break
+		}
+		delay = retryJitter.Delay(attempt, delay)
+		time.Sleep(delay)
 	}
+	statusTracker.completedFiles.Add(1)
 }
 
-func downloadFile(srv *drive.Service, f *drive.File, filePath string, statusTracker *statusTracker) {
-	if _, error := os.Stat(filePath); error == nil {
-		statusTracker.skippedFiles.Add(1)
-		return
+func processFileJob(ctx context.Context, client *driveClient, job *fileJob, statusTracker *statusTracker) (bool, error) {
+	if *touchOnly {
+		return false, touchLocalFile(job, statusTracker)
+	}
+	if error := os.MkdirAll(filepath.Dir(job.localPath), 0755); error != nil {
+		log.Printf("ao criar diretório local '%s': %v", filepath.Dir(job.localPath), error)
+		abortOnDiskError(error)
+		return false, error
+	}
+	if strings.HasPrefix(job.file.MimeType, "application/vnd.google-apps") {
+		return convertGoogleFileType(ctx, client, job, statusTracker)
 	}
+	return downloadFile(ctx, client, job, statusTracker)
+}
 
-	log.Println(filePath)
-	tempFilePath := filePath + ".tmp"
-	resp, error := srv.Files.Get(f.Id).Download()
+func downloadFile(ctx context.Context, client *driveClient, job *fileJob, statusTracker *statusTracker) (bool, error) {
+	f, filePath := job.file, job.localPath
+	if existsInDestination(job, filePath) {
+		statusTracker.skip(job, "arquivo já existe")
+		return false, nil
+	}
+	if _, error := os.Stat(filePath); error == nil && !job.replaceExisting && !*force {
+		target := resolveConflict(f, filePath)
+		if target == "" {
+			rememberExistingFile(f, filePath)
+			statusTracker.skip(job, "arquivo já existe")
+			return false, nil
+		}
+		filePath = target
+	}
+	var manifestSum []byte
+	if linkToIdenticalFile(f, filePath) {
+		if manifestHash := newManifestHash(); manifestHash != nil {
+			if _, error := hashFile(filePath, manifestHash); error == nil {
+				manifestSum = manifestHash.Sum(nil)
+			}
+		}
+	} else {
+		log.Println(filePath)
+		tempFilePath := filePath + ".tmp"
+		var error error
+		if chunkThreshold > 0 && f.Size > int64(chunkThreshold) && *chunks > 1 {
+			manifestSum, error = downloadFileChunked(ctx, client, f, tempFilePath)
+		} else {
+			manifestSum, error = downloadFileStream(ctx, client, f, tempFilePath)
+		}
+		if apiErrorCode(error) == http.StatusGone {
+			skippedLog.Printf("file no longer available '%s': %s", f.Name, formatAPIError(error))
+			statusTracker.skip(job, "arquivo não está mais disponível")
+			return false, nil
+		}
+		if isAccessDenied(error) {
+			skippedLog.Printf("access denied '%s': %s", f.Name, formatAPIError(error))
+			statusTracker.deny(job, error)
+			return false, nil
+		}
+		if error != nil {
+			log.Printf("download '%s': %s", f.Name, formatAPIError(error))
+			abortOnDiskError(error)
+			return false, error
+		}
+
+		backupExistingFile(filePath)
+		if error := os.Rename(tempFilePath, filePath); error != nil {
+			log.Printf("rename '%s': %v", filePath, error)
+			abortOnDiskError(error)
+			return false, error
+		}
+	}
+	if manifestSum != nil {
+		appendToManifest(filePath, manifestSum)
+	}
+	rememberDownloadedFile(f, filePath)
+	exportSidecars(ctx, client, f, filePath)
+	return true, storeInDestination(job, filePath)
+}
+
+func downloadFileStream(ctx context.Context, client *driveClient, f *drive.File, tempFilePath string) ([]byte, error) {
+	if error := client.wait(ctx); error != nil {
+		return nil, error
+	}
+	call := client.service.Files.Get(f.Id).SupportsAllDrives(true).Context(ctx)
+	setResourceKeyHeader(call.Header(), f)
+	resp, error := call.Download()
+	client.done(error)
 	if error != nil {
-		log.Printf("download '%s': %v", f.Name, error)
-		return
+		return nil, error
 	}
 	defer resp.Body.Close()
 
 	out, error := os.Create(tempFilePath)
 	if error != nil {
-		log.Printf("create temp '%s': %v", tempFilePath, error)
-		return
+		return nil, fmt.Errorf("create temp '%s': %w", tempFilePath, error)
 	}
 	defer out.Close()
 
-	_, error = io.Copy(out, resp.Body)
+	md5Hash := md5.New()
+	manifestHash := newManifestHash()
+	writer := io.Writer(out)
+	if manifestHash != nil {
+		writer = io.MultiWriter(out, manifestHash)
+	}
+	_, error = io.Copy(writer, io.TeeReader(withActivity(ctx, resp.Body), md5Hash))
 	if error != nil {
 		out.Close()
 		os.Remove(tempFilePath)
-		log.Printf("copy '%s': %v", f.Name, error)
-		return
+		return nil, fmt.Errorf("copy: %w", error)
 	}
-
-	if error := os.Rename(tempFilePath, filePath); error != nil {
-		log.Printf("rename '%s': %v", filePath, error)
+	if sum := hex.EncodeToString(md5Hash.Sum(nil)); f.Md5Checksum != "" && sum != f.Md5Checksum {
+		out.Close()
+		os.Remove(tempFilePath)
+		return nil, fmt.Errorf("md5 mismatch: expected %s, got %s", f.Md5Checksum, sum)
 	}
+	if manifestHash == nil {
+		return nil, nil
+	}
+	return manifestHash.Sum(nil), nil
 }
 
-func convertGoogleFileType(driveService *drive.Service, driveFile *drive.File, filePath string, statusTracker *statusTracker) {
-	var exportMimeType, extension string
-	switch driveFile.MimeType {
-	case "application/vnd.google-apps.document":
-		exportMimeType, extension = "application/vnd.openxmlformats-officedocument.wordprocessingml.document", ".docx"
-	case "application/vnd.google-apps.spreadsheet":
-		exportMimeType, extension = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx"
-	case "application/vnd.google-apps.presentation":
-		exportMimeType, extension = "application/vnd.openxmlformats-officedocument.presentationml.presentation", ".pptx"
-	default:
-		return
+func convertGoogleFileType(ctx context.Context, client *driveClient, job *fileJob, statusTracker *statusTracker) (bool, error) {
+	driveFile, filePath := job.file, job.localPath
+	exportMimeType, extension := exportFormat(driveFile.MimeType)
+	if exportMimeType == "" {
+		return false, nil
 	}
 
 	finalFilePath := filePath + extension
-	if _, error := os.Stat(finalFilePath); error == nil {
-		statusTracker.skippedFiles.Add(1)
-		return
+	if existsInDestination(job, finalFilePath) {
+		statusTracker.skip(job, "arquivo já existe")
+		return false, nil
+	}
+	if _, error := os.Stat(finalFilePath); error == nil && !job.replaceExisting && !*force {
+		target := resolveConflict(driveFile, finalFilePath)
+		if target == "" {
+			statusTracker.skip(job, "arquivo já existe")
+			return false, nil
+		}
+		finalFilePath = target
 	}
 
 	log.Println(filePath)
 	tempFilePath := finalFilePath + ".tmp"
-	response, error := driveService.Files.Export(driveFile.Id, exportMimeType).Download()
+	if error := client.wait(ctx); error != nil {
+		errorLog.Printf("export '%s': %s", driveFile.Name, formatAPIError(error))
+		return false, error
+	}
+	call := client.service.Files.Export(driveFile.Id, exportMimeType).Context(ctx)
+	setResourceKeyHeader(call.Header(), driveFile)
+	response, error := call.Download()
+	client.done(error)
+	if *splitLargeExports && apiErrorReason(error) == "exportSizeLimitExceeded" && exportMimeType != "application/pdf" {
+		log.Printf("aviso: '%s' é grande demais para exportar como %s, exportando como PDF", driveFile.Name, extension)
+		finalFilePath = strings.TrimSuffix(finalFilePath, extension) + ".pdf"
+		tempFilePath = finalFilePath + ".tmp"
+		if error := client.wait(ctx); error != nil {
+			errorLog.Printf("export '%s': %s", driveFile.Name, formatAPIError(error))
+			return false, error
+		}
+		call := client.service.Files.Export(driveFile.Id, "application/pdf").Context(ctx)
+		setResourceKeyHeader(call.Header(), driveFile)
+		response, error = call.Download()
+		client.done(error)
+		if error != nil {
+			skippedLog.Printf("export as PDF failed '%s': %s", driveFile.Name, formatAPIError(error))
+		}
+		if isAccessDenied(error) && apiErrorReason(error) != "cannotExportFile" {
+			statusTracker.skip(job, "grande demais para exportar, inclusive como PDF")
+			return false, nil
+		}
+	}
+	if apiErrorCode(error) == http.StatusGone {
+		skippedLog.Printf("file no longer available '%s': %s", driveFile.Name, formatAPIError(error))
+		statusTracker.skip(job, "arquivo não está mais disponível")
+		return false, nil
+	}
+	if apiErrorReason(error) == "cannotExportFile" {
+		restrictedLog.Printf("export restricted '%s' (%s): %s", job.localPath, driveFile.Id, formatAPIError(error))
+		statusTracker.restrict(job, error)
+		return false, nil
+	}
+	if isAccessDenied(error) {
+		skippedLog.Printf("access denied '%s': %s", driveFile.Name, formatAPIError(error))
+		statusTracker.deny(job, error)
+		return false, nil
+	}
+	if driveFile.MimeType == "application/vnd.google-apps.map" && exportUnsupported(error) {
+		skippedLog.Printf("KML export failed '%s': %s", driveFile.Name, formatAPIError(error))
+		statusTracker.skip(job, "não foi possível exportar o mapa como KML")
+		return false, nil
+	}
+	if driveFile.MimeType == "application/vnd.google-apps.jam" && exportUnsupported(error) {
+		skippedLog.Printf("Jamboard unsupported '%s': %s", driveFile.Name, formatAPIError(error))
+		statusTracker.skip(job, "o Drive não permite exportar este Jamboard; baixe-o manualmente antes que o Jamboard seja desativado")
+		return false, nil
+	}
 	if error != nil {
-		errorLog.Printf("export '%s': %v", driveFile.Name, error)
-		return
+		errorLog.Printf("export '%s': %s", driveFile.Name, formatAPIError(error))
+		return false, error
 	}
 	defer response.Body.Close()
 
 	out, error := os.Create(tempFilePath)
 	if error != nil {
 		errorLog.Printf("create temp '%s': %v", tempFilePath, error)
-		return
+		abortOnDiskError(error)
+		return false, error
 	}
 	defer out.Close()
 
-	_, error = io.Copy(out, response.Body)
+	_, error = io.Copy(out, withActivity(ctx, response.Body))
 	if error != nil {
 		out.Close()
 		os.Remove(tempFilePath)
 		errorLog.Printf("copy response to file '%s': %v", driveFile.Name, error)
-		return
+		abortOnDiskError(error)
+		return false, error
 	}
 
+	backupExistingFile(finalFilePath)
 	if error := os.Rename(tempFilePath, finalFilePath); error != nil {
 		errorLog.Printf("rename '%s': %v", finalFilePath, error)
+		abortOnDiskError(error)
+		return false, error
 	}
+	exportSidecars(ctx, client, driveFile, finalFilePath)
+	return true, storeInDestination(job, finalFilePath)
 }
 
-func discoverAndQueueFiles(driveService *drive.Service, folderID, localPath string, channelFileJob chan<- *fileJob, downloadWaitGroup, discoveryWaitGroup *sync.WaitGroup, statusTracker *statusTracker) {
+func exportFormat(mimeType string) (string, string) {
+	switch mimeType {
+	case "application/vnd.google-apps.document":
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document", ".docx"
+	case "application/vnd.google-apps.spreadsheet":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx"
+	case "application/vnd.google-apps.presentation":
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation", ".pptx"
+	case "application/vnd.google-apps.form":
+		if *skipForms {
+			return "", ""
+		}
+		return "application/pdf", ".pdf"
+	case "application/vnd.google-apps.script":
+		return "application/vnd.google-apps.script+json", ".gs.json"
+	case "application/vnd.google-apps.map":
+		if *skipMaps {
+			return "", ""
+		}
+		return "application/vnd.google-earth.kml+xml", ".kml"
+	case "application/vnd.google-apps.jam":
+		return "application/pdf", ".pdf"
+	case "application/vnd.google-apps.site":
+		if *skipSites {
+			return "", ""
+		}
+		return "application/zip", "_site.zip"
+	case "application/vnd.google-apps.drawing":
+		format := drawingExportFormats[*drawingFormat]
+		return format[0], format[1]
+	}
+	return "", ""
+}
+
+func trashLocalPath(rootPath, filePath string) string {
+	relativePath, error := filepath.Rel(rootPath, filePath)
+	if error != nil {
+		relativePath = filepath.Base(filePath)
+	}
+	return filepath.Join(rootPath, "_trash", relativePath)
+}
+
+func abortOnDiskError(error error) {
+	var errno syscall.Errno
+	if !errors.As(error, &errno) || (errno != syscall.ENOSPC && errno != syscall.EROFS) {
+		return
+	}
+	diskErrorOnce.Do(func() {
+		diskError = error
+		errorLog.Printf("disk error, aborting: %v", error)
+		cancelRun()
+	})
+}
+
+func discoverAndQueueFiles(ctx context.Context, client *driveClient, folderID, localPath string, channelFileJob chan<- *fileJob, discoveryWaitGroup *sync.WaitGroup, statusTracker *statusTracker) {
 	defer discoveryWaitGroup.Done()
+	discoveryPool := make(chan struct{}, *discoveryWorkers)
+	queueFile := func(file *drive.File, parentID, folderLocalPath string) {
+		job := &fileJob{file: file, localPath: fileLocalPath(file, parentID, localPath, folderLocalPath)}
+		if file.Trashed {
+			job.localPath = trashLocalPath(localPath, job.localPath)
+		}
+		inventory.add(job)
+		structure.addFile(parentID)
+		recordMediaDirectory(job)
+		statusTracker.totalFilesFound.Add(1)
+		channelFileJob <- job
+	}
+	var flatPending []flatPendingFile
+	var flatPendingMutex sync.Mutex
+	fields := fileFields()
+	if *trashed || *trashedOnly {
+		fields += ", trashed"
+	}
+	var folderWaitGroup sync.WaitGroup
 	var discover func(string, string)
 	discover = func(currentFolderId, currentLocalPath string) {
-		if error := os.MkdirAll(currentLocalPath, 0755); error != nil {
-			log.Printf("ao criar diretório local '%s': %v", currentLocalPath, error)
-			return
+		defer folderWaitGroup.Done()
+		if mirrorsFolders() {
+			if error := os.MkdirAll(currentLocalPath, 0755); error != nil {
+				log.Printf("ao criar diretório local '%s': %v", currentLocalPath, error)
+				abortOnDiskError(error)
+				return
+			}
 		}
 		var pageToken string
 		for {
-			query := fmt.Sprintf("'%s' in parents and trashed=false", currentFolderId)
-			driveFileList, error := driveService.Files.List().Q(query).PageSize(1000).Fields("nextPageToken, files(id, name, mimeType)").PageToken(pageToken).Do()
+			if ctx.Err() != nil {
+				return
+			}
+			query := fmt.Sprintf("'%s' in parents", currentFolderId)
+			switch {
+			case *trashedOnly:
+				query += " and (trashed=true or mimeType='application/vnd.google-apps.folder')"
+			case !*trashed:
+				query += " and trashed=false"
+			}
+			if *starred {
+				query += " and (starred=true or mimeType='application/vnd.google-apps.folder')"
+			}
+			discoveryPool <- struct{}{}
+			if error := client.wait(ctx); error != nil {
+				<-discoveryPool
+				log.Printf("ao listar arquivos na pasta ID '%s': %s", currentFolderId, formatAPIError(error))
+				return
+			}
+			driveFileList, error := listFiles(client.service).Q(query).PageSize(*pageSize).Fields("nextPageToken, files(" + fields + ")").PageToken(pageToken).Do()
+			client.done(error)
+			<-discoveryPool
+			if apiErrorCode(error) == http.StatusGone {
+				skippedLog.Printf("folder no longer available '%s': %s", currentLocalPath, formatAPIError(error))
+				return
+			}
 			if error != nil {
-				log.Printf("ao listar arquivos na pasta ID '%s': %v", currentFolderId, error)
+				log.Printf("ao listar arquivos na pasta ID '%s': %s", currentFolderId, formatAPIError(error))
 				return
 			}
 			for _, file := range driveFileList.Files {
-				sanitizedName := sanitizeFileName(file.Name)
-				newLocalPath := filepath.Join(currentLocalPath, sanitizedName)
 				if file.MimeType == "application/vnd.google-apps.folder" {
-					discover(file.Id, newLocalPath)
+					newLocalPath := filepath.Join(currentLocalPath, sanitizeFileName(file.Name))
+					structure.addFolder(currentFolderId, file.Id, file.Name, newLocalPath)
+					folderWaitGroup.Add(1)
+					go discover(file.Id, newLocalPath)
 				} else {
-					statusTracker.totalFilesFound.Add(1)
-					downloadWaitGroup.Add(1)
-					channelFileJob <- &fileJob{file: file, localPath: newLocalPath}
+					useOriginalFilename(file)
+					if *flat {
+						flatPendingMutex.Lock()
+						flatPending = append(flatPending, flatPendingFile{file: file, parentID: currentFolderId})
+						flatPendingMutex.Unlock()
+					} else {
+						queueFile(file, currentFolderId, currentLocalPath)
+					}
 				}
 			}
 			pageToken = driveFileList.NextPageToken
@@ -278,38 +900,121 @@ func discoverAndQueueFiles(driveService *drive.Service, folderID, localPath stri
 			}
 		}
 	}
+	structure.addRoot(ctx, client, folderID, localPath)
+	folderWaitGroup.Add(1)
 	discover(folderID, localPath)
+	folderWaitGroup.Wait()
+
+	sort.Slice(flatPending, func(i, j int) bool { return flatPending[i].file.Id < flatPending[j].file.Id })
+	for _, pending := range flatPending {
+		queueFile(pending.file, pending.parentID, localPath)
+	}
 }
 
 func authenticate(ctx context.Context) *drive.Service {
-	b, error := ioutil.ReadFile("credentials.json")
-	if error != nil {
-		log.Fatalf("Não foi possível ler o arquivo de credenciais (credentials.json): %v", error)
+	scopes := requestedScopes()
+	if path := scopeTokenFile(scopes); path != tokenFile && *rcloneConfig == "" {
+		if _, error := os.Stat(path); os.IsNotExist(error) {
+			log.Printf("o escopo pedido exige uma nova autorização no navegador; o token será salvo em '%s' e '%s' não é alterado", path, tokenFile)
+		}
+	}
+	client := authenticatedClient(ctx, scopes...)
+	if *exportActivity {
+		activityService = newActivityService(ctx, client)
 	}
-	config, error := google.ConfigFromJSON(b, drive.DriveReadonlyScope)
+	if *exportLabels {
+		labelsService = newLabelsService(ctx, client)
+	}
+	return newDriveService(ctx, client)
+}
+
+func requestedScopes() []string {
+	scope, ok := oauthScopes[*oauthScope]
+	if !ok {
+		fatalf("Valor inválido para --scope: '%s' (use readonly, readwrite ou metadata)", *oauthScope)
+	}
+	scopes := []string{scope}
+	if *exportActivity {
+		scopes = append(scopes, driveactivity.DriveActivityReadonlyScope)
+	}
+	if *exportLabels {
+		scopes = append(scopes, drivelabels.DriveLabelsReadonlyScope)
+	}
+	return scopes
+}
+
+func authenticateWithScope(ctx context.Context, scopes ...string) *drive.Service {
+	return newDriveService(ctx, authenticatedClient(ctx, scopes...))
+}
+
+func authenticatedClient(ctx context.Context, scopes ...string) *http.Client {
+	client, error := newAuthenticatedClient(ctx, scopes)
 	if error != nil {
-		log.Fatalf("Não foi possível processar o arquivo de credenciais: %v", error)
+		fatalf("Não foi possível autenticar: %v", error)
 	}
-	client := getClient(config)
-	srv, error := drive.NewService(ctx, option.WithHTTPClient(client))
+	return client
+}
+
+func newAuthenticatedClient(ctx context.Context, scopes []string) (*http.Client, error) {
+	if *rcloneConfig != "" {
+		config, token, error := rcloneCredentials(scopes)
+		if error != nil {
+			return nil, fmt.Errorf("não foi possível usar as credenciais do rclone: %w", error)
+		}
+		return config.Client(httpClientContext(), token), nil
+	}
+	return credentialsClient(ctx, scopes)
+}
+
+func newDriveService(ctx context.Context, client *http.Client) *drive.Service {
+	srv, error := driveServiceForClient(ctx, client)
 	if error != nil {
-		log.Fatalf("Não foi possível criar o serviço do Drive: %v", error)
+		fatalf("Não foi possível criar o serviço do Drive: %v", error)
 	}
 	return srv
 }
 
+func driveServiceForClient(ctx context.Context, client *http.Client) (*drive.Service, error) {
+	options := []option.ClientOption{option.WithHTTPClient(client)}
+	if *apiEndpoint != "" {
+		options = append(options, option.WithEndpoint(strings.TrimSuffix(*apiEndpoint, "/")+"/"))
+	}
+	return NewDriveService(ctx, options, apiVersion)
+}
+
+func NewDriveService(ctx context.Context, options []option.ClientOption, apiVersion string) (*drive.Service, error) {
+	switch apiVersion {
+	case "v3":
+		return drive.NewService(ctx, options...)
+	}
+	return nil, fmt.Errorf("versão da API do Drive não suportada: %s", apiVersion)
+}
+
 func getDriveFolderIDByPath(driveService *drive.Service, path string) (string, error) {
 	if path == "" || path == "root" {
 		return "root", nil
 	}
+	if folderID, ok := folderIDFromURL(path); ok {
+		return folderID, nil
+	}
 	parts := strings.Split(path, "/")
 	currentParentID := "root"
 	for _, part := range parts {
 		if part == "" {
 			continue
 		}
-		query := fmt.Sprintf("mimeType='application/vnd.google-apps.folder' and name='%s' and '%s' in parents and trashed=false", part, currentParentID)
-		r, error := driveService.Files.List().Q(query).Fields("files(id)").PageSize(1).Do()
+		if currentParentID == "root" && *corpora == "allDrives" {
+			drives, error := driveService.Drives.List().Q(fmt.Sprintf("name='%s'", escapeQuery(part))).Fields("drives(id)").PageSize(1).Do()
+			if error != nil {
+				return "", fmt.Errorf("falha ao buscar pelo drive compartilhado '%s': %v", part, error)
+			}
+			if len(drives.Drives) > 0 {
+				currentParentID = drives.Drives[0].Id
+				continue
+			}
+		}
+		query := fmt.Sprintf("mimeType='application/vnd.google-apps.folder' and name='%s' and '%s' in parents and trashed=false", escapeQuery(part), currentParentID)
+		r, error := listFiles(driveService).Q(query).Fields("files(id)").PageSize(1).Do()
 		if error != nil {
 			return "", fmt.Errorf("falha ao buscar pela pasta '%s': %v", part, error)
 		}
@@ -321,6 +1026,81 @@ func getDriveFolderIDByPath(driveService *drive.Service, path string) (string, e
 	return currentParentID, nil
 }
 
+func folderIDFromURL(rawURL string) (string, bool) {
+	parsed, error := url.Parse(rawURL)
+	if error != nil || parsed.Host != "drive.google.com" {
+		return "", false
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment == "folders" && i+1 < len(segments) && segments[i+1] != "" {
+			return segments[i+1], true
+		}
+	}
+	return "", false
+}
+
+func listFiles(driveService *drive.Service) *drive.FilesListCall {
+	return driveService.Files.List().Corpora(*corpora).IncludeItemsFromAllDrives(true).SupportsAllDrives(true)
+}
+
+func exportUnsupported(error error) bool {
+	code := apiErrorCode(error)
+	return code == http.StatusInternalServerError || (code >= 400 && code < 500 && code != http.StatusTooManyRequests)
+}
+
+func apiErrorCode(error error) int {
+	var apiError *googleapi.Error
+	if errors.As(error, &apiError) {
+		return apiError.Code
+	}
+	return 0
+}
+
+func apiErrorReason(error error) string {
+	var apiError *googleapi.Error
+	if errors.As(error, &apiError) && len(apiError.Errors) > 0 {
+		return apiError.Errors[0].Reason
+	}
+	return ""
+}
+
+func formatAPIError(error error) string {
+	var apiError *googleapi.Error
+	if !errors.As(error, &apiError) {
+		return fmt.Sprint(error)
+	}
+	message := fmt.Sprintf("HTTP %d", apiError.Code)
+	if reason := apiErrorReason(error); reason != "" {
+		message += " (" + reason + ")"
+	}
+	if apiError.Message != "" {
+		message += ": " + apiError.Message
+	}
+	return message
+}
+
+func isAccessDenied(error error) bool {
+	if apiErrorCode(error) != http.StatusForbidden {
+		return false
+	}
+	switch apiErrorReason(error) {
+	case "rateLimitExceeded", "userRateLimitExceeded", "dailyLimitExceeded":
+		return false
+	}
+	return true
+}
+
+func setResourceKeyHeader(header http.Header, file *drive.File) {
+	if file.ResourceKey != "" {
+		header.Set("X-Goog-Drive-Resource-Keys", file.Id+"/"+file.ResourceKey)
+	}
+}
+
+func escapeQuery(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
+
 func sanitizeFileName(fileName string) string {
 	invalidChars := []string{"\\", "/", ":", "*", "?", "\"", "<", ">", "|"}
 	for _, char := range invalidChars {
@@ -329,26 +1109,65 @@ func sanitizeFileName(fileName string) string {
 	return fileName
 }
 
-func getClient(config *oauth2.Config) *http.Client {
-	tokFile := "token.json"
-	tok, error := tokenFromFile(tokFile)
+var oauthScopes = map[string]string{
+	"readonly":  drive.DriveReadonlyScope,
+	"readwrite": drive.DriveScope,
+	"metadata":  drive.DriveMetadataScope,
+}
+
+var scopeTokenSuffixes = map[string]string{
+	drive.DriveScope:                         "_write",
+	drive.DriveMetadataScope:                 "_metadata",
+	driveactivity.DriveActivityReadonlyScope: "_activity",
+	drivelabels.DriveLabelsReadonlyScope:     "_labels",
+}
+
+func scopeTokenFile(scopes []string) string {
+	name := strings.TrimSuffix(tokenFile, ".json")
+	for _, scope := range scopes {
+		name += scopeTokenSuffixes[scope]
+	}
+	return name + ".json"
+}
+
+var tokenFileMutex sync.Mutex
+
+func getClient(ctx context.Context, config *oauth2.Config, tokenPath string) (*http.Client, error) {
+	tokenFileMutex.Lock()
+	defer tokenFileMutex.Unlock()
+	tok, error := tokenFromFile(tokenPath)
+	if error == nil && tok.Expiry.Before(time.Now()) {
+		refreshed, refreshError := config.TokenSource(ctx, tok).Token()
+		var retrieveError *oauth2.RetrieveError
+		if errors.As(refreshError, &retrieveError) && retrieveError.ErrorCode == "invalid_grant" {
+			log.Printf("o token de acesso em '%s' expirou e não pôde ser renovado, autorize novamente: %v", tokenPath, refreshError)
+			os.Remove(tokenPath)
+			error = refreshError
+		} else if refreshError != nil {
+			return nil, fmt.Errorf("não foi possível renovar o token de acesso em '%s' (o arquivo foi mantido, tente novamente): %w", tokenPath, refreshError)
+		} else if refreshed.AccessToken != tok.AccessToken {
+			tok = refreshed
+			saveToken(tokenPath, tok)
+		}
+	}
 	if error != nil {
-		tok = getTokenFromWeb(config)
-		saveToken(tokFile, tok)
+		tok = getTokenFromWeb(ctx, config)
+		saveToken(tokenPath, tok)
 	}
-	return config.Client(context.Background(), tok)
+	return config.Client(httpClientContext(), tok), nil
 }
 
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+func getTokenFromWeb(ctx context.Context, config *oauth2.Config) *oauth2.Token {
+	verifier := oauth2.GenerateVerifier()
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
 	fmt.Printf("Acesse o seguinte link no seu navegador e cole o código de autorização aqui: \n%v\n", authURL)
 	var authCode string
 	if _, error := fmt.Scan(&authCode); error != nil {
-		log.Fatalf("Não foi possível ler o código de autorização: %v", error)
+		fatalf("Não foi possível ler o código de autorização: %v", error)
 	}
-	tok, error := config.Exchange(context.TODO(), authCode)
+	tok, error := config.Exchange(ctx, authCode, oauth2.VerifierOption(verifier))
 	if error != nil {
-		log.Fatalf("Não foi possível trocar o código pelo token: %v", error)
+		fatalf("Não foi possível trocar o código pelo token: %v", error)
 	}
 	return tok
 }
@@ -359,16 +1178,28 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
 		return nil, error
 	}
 	defer f.Close()
+	if info, error := f.Stat(); error == nil && info.Size() > maxTokenFileSize {
+		log.Printf("o token de acesso em '%s' tem %s (acima de %s) e será gerado novamente", file, humanSize(info.Size()), humanSize(maxTokenFileSize))
+		os.Remove(file)
+		return nil, fmt.Errorf("'%s' é grande demais", file)
+	}
 	tok := &oauth2.Token{}
 	error = json.NewDecoder(f).Decode(tok)
+	if errors.Is(error, io.ErrUnexpectedEOF) {
+		log.Printf("o token de acesso em '%s' está truncado e será gerado novamente", file)
+		os.Remove(file)
+	}
 	return tok, error
 }
 
 func saveToken(path string, token *oauth2.Token) {
 	fmt.Printf("Salvando o token de acesso em: %s\n", path)
+	if error := os.MkdirAll(filepath.Dir(path), 0700); error != nil {
+		fatalf("Não foi possível criar o diretório do token: %v", error)
+	}
 	f, error := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if error != nil {
-		log.Fatalf("Não foi possível salvar o token: %v", error)
+		fatalf("Não foi possível salvar o token: %v", error)
 	}
 	defer f.Close()
 	json.NewEncoder(f).Encode(token)
@@ -0,0 +1,606 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+func newTestDriveService(t *testing.T, handler http.HandlerFunc) *drive.Service {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	driveService, error := NewDriveService(context.Background(), []option.ClientOption{option.WithEndpoint(server.URL + "/"), option.WithHTTPClient(server.Client())}, apiVersion)
+	if error != nil {
+		t.Fatalf("NewDriveService: %v", error)
+	}
+	return driveService
+}
+
+func TestDownloadWorkersDrainQueue(t *testing.T) {
+	const roots, foldersPerRoot, filesPerFolder = 4, 5, 10
+	children := map[string][]*drive.File{}
+	var want []string
+	for r := 0; r < roots; r++ {
+		rootID := fmt.Sprintf("root-%d", r)
+		for f := 0; f < foldersPerRoot; f++ {
+			folderID := fmt.Sprintf("%s-folder-%d", rootID, f)
+			children[rootID] = append(children[rootID], &drive.File{Id: folderID, Name: folderID, MimeType: "application/vnd.google-apps.folder"})
+			for i := 0; i < filesPerFolder; i++ {
+				name := fmt.Sprintf("%s-file-%d", folderID, i)
+				children[folderID] = append(children[folderID], &drive.File{Id: name, Name: name, MimeType: "text/plain", Size: int64(len(name))})
+				want = append(want, filepath.Join(rootID, folderID, name))
+			}
+		}
+	}
+	parent := regexp.MustCompile(`'([^']*)' in parents`)
+	driveService := newTestDriveService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/files" {
+			fmt.Fprint(w, strings.TrimPrefix(r.URL.Path, "/files/"))
+			return
+		}
+		match := parent.FindStringSubmatch(r.URL.Query().Get("q"))
+		if match == nil {
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(&drive.FileList{Files: children[match[1]]})
+	})
+
+	ctx := context.Background()
+	client := newDriveClient(driveService, 0)
+	dir := t.TempDir()
+	channelFileJob := make(chan *fileJob)
+	tracker := statusTracker{}
+	pool := newWorkerPool(channelFileJob, 4, 4, func(job *fileJob, heartbeat *workerHeartbeat) {
+		handleFileJob(ctx, client, job, &tracker, heartbeat)
+	})
+	pool.start()
+
+	var discoveryWaitGroup sync.WaitGroup
+	for r := 0; r < roots; r++ {
+		rootID := fmt.Sprintf("root-%d", r)
+		discoveryWaitGroup.Add(1)
+		go discoverAndQueueFiles(ctx, client, rootID, filepath.Join(dir, rootID), channelFileJob, &discoveryWaitGroup, &tracker)
+	}
+	go func() {
+		discoveryWaitGroup.Wait()
+		close(channelFileJob)
+	}()
+	pool.wait()
+
+	if completed := tracker.completedFiles.Load(); completed != int32(len(want)) {
+		t.Fatalf("completedFiles = %d, want %d", completed, len(want))
+	}
+	for _, relativePath := range want {
+		content, error := os.ReadFile(filepath.Join(dir, relativePath))
+		if error != nil {
+			t.Fatalf("read %s: %v", relativePath, error)
+		}
+		if name := filepath.Base(relativePath); string(content) != name {
+			t.Errorf("%s content = %q, want %q", relativePath, content, name)
+		}
+	}
+}
+
+func TestGetDriveFolderIDByPath(t *testing.T) {
+	folders := map[string]map[string]string{
+		"root":  {"photos": "photos-id", "a": "a-id"},
+		"a-id":  {"b": "b-id"},
+		"b-id":  {"c": "c-id"},
+		"c-id":  {},
+		"other": {"photos": "wrong-id"},
+	}
+	query := regexp.MustCompile(`name='([^']*)' and '([^']*)' in parents`)
+	driveService := newTestDriveService(t, func(w http.ResponseWriter, r *http.Request) {
+		match := query.FindStringSubmatch(r.URL.Query().Get("q"))
+		if match == nil {
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		if id, ok := folders[match[2]][match[1]]; ok {
+			fmt.Fprintf(w, `{"files": [{"id": %q}]}`, id)
+			return
+		}
+		fmt.Fprint(w, `{"files": []}`)
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty path", path: "", want: "root"},
+		{name: "root", path: "root", want: "root"},
+		{name: "one folder deep", path: "photos", want: "photos-id"},
+		{name: "three folders deep", path: "a/b/c", want: "c-id"},
+		{name: "leading and trailing slashes", path: "/a/b/", want: "b-id"},
+		{name: "empty components", path: "a//b///c", want: "c-id"},
+		{name: "not found", path: "missing", wantErr: true},
+		{name: "not found below a folder", path: "a/b/missing", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, error := getDriveFolderIDByPath(driveService, test.path)
+			if test.wantErr {
+				if error == nil {
+					t.Fatalf("getDriveFolderIDByPath(%q) = %q, want error", test.path, got)
+				}
+				return
+			}
+			if error != nil {
+				t.Fatalf("getDriveFolderIDByPath(%q): %v", test.path, error)
+			}
+			if got != test.want {
+				t.Errorf("getDriveFolderIDByPath(%q) = %q, want %q", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		want     string
+	}{
+		{name: "backslash", fileName: `a\b`, want: "a_b"},
+		{name: "slash", fileName: "a/b", want: "a_b"},
+		{name: "colon", fileName: "a:b", want: "a_b"},
+		{name: "asterisk", fileName: "a*b", want: "a_b"},
+		{name: "question mark", fileName: "a?b", want: "a_b"},
+		{name: "double quote", fileName: `a"b`, want: "a_b"},
+		{name: "less than", fileName: "a<b", want: "a_b"},
+		{name: "greater than", fileName: "a>b", want: "a_b"},
+		{name: "pipe", fileName: "a|b", want: "a_b"},
+		{name: "unicode", fileName: "relatório 日本語 ✓.txt", want: "relatório 日本語 ✓.txt"},
+		{name: "empty", fileName: "", want: ""},
+		{name: "only invalid characters", fileName: `\/:*?"<>|`, want: "_________"},
+		{name: "mixed valid and invalid", fileName: "notas: 2024/01?.txt", want: "notas_ 2024_01_.txt"},
+		{name: "valid", fileName: "report-final.pdf", want: "report-final.pdf"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sanitizeFileName(test.fileName); got != test.want {
+				t.Errorf("sanitizeFileName(%q) = %q, want %q", test.fileName, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	const content = "conteúdo de teste"
+	var downloads atomic.Int32
+	driveService := newTestDriveService(t, func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			w.Header().Set("Content-Length", "1000")
+		}
+		fmt.Fprint(w, content)
+	})
+	client := newDriveClient(driveService, 0)
+
+	t.Run("downloads to a temp file and renames it", func(t *testing.T) {
+		downloads.Store(0)
+		filePath := filepath.Join(t.TempDir(), "file.txt")
+		job := &fileJob{file: &drive.File{Id: "file", Name: "file.txt"}, localPath: filePath}
+		written, error := downloadFile(context.Background(), client, job, &statusTracker{})
+		if error != nil {
+			t.Fatalf("downloadFile: %v", error)
+		}
+		if !written {
+			t.Error("written = false, want true")
+		}
+		got, error := os.ReadFile(filePath)
+		if error != nil {
+			t.Fatalf("read %s: %v", filePath, error)
+		}
+		if string(got) != content {
+			t.Errorf("content = %q, want %q", got, content)
+		}
+		if _, error := os.Stat(filePath + ".tmp"); !os.IsNotExist(error) {
+			t.Errorf("temp file still exists: %v", error)
+		}
+		if count := downloads.Load(); count != 1 {
+			t.Errorf("downloads = %d, want 1", count)
+		}
+	})
+
+	t.Run("skips an existing file", func(t *testing.T) {
+		downloads.Store(0)
+		filePath := filepath.Join(t.TempDir(), "file.txt")
+		if error := os.WriteFile(filePath, []byte("local"), 0644); error != nil {
+			t.Fatal(error)
+		}
+		tracker := statusTracker{}
+		job := &fileJob{file: &drive.File{Id: "file", Name: "file.txt"}, localPath: filePath}
+		written, error := downloadFile(context.Background(), client, job, &tracker)
+		if error != nil {
+			t.Fatalf("downloadFile: %v", error)
+		}
+		if written {
+			t.Error("written = true, want false for a skipped file")
+		}
+		if count := downloads.Load(); count != 0 {
+			t.Errorf("downloads = %d, want 0", count)
+		}
+		if skipped := tracker.skippedFiles.Load(); skipped != 1 {
+			t.Errorf("skippedFiles = %d, want 1", skipped)
+		}
+		if got, _ := os.ReadFile(filePath); string(got) != "local" {
+			t.Errorf("content = %q, want %q", got, "local")
+		}
+	})
+
+	t.Run("removes the temp file when the copy fails", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "broken.txt")
+		job := &fileJob{file: &drive.File{Id: "broken", Name: "broken.txt"}, localPath: filePath}
+		if _, error := downloadFile(context.Background(), client, job, &statusTracker{}); error == nil {
+			t.Fatal("downloadFile succeeded, want error")
+		}
+		if _, error := os.Stat(filePath + ".tmp"); !os.IsNotExist(error) {
+			t.Errorf("temp file still exists: %v", error)
+		}
+		if _, error := os.Stat(filePath); !os.IsNotExist(error) {
+			t.Errorf("final file exists: %v", error)
+		}
+	})
+
+	t.Run("logs a rename failure", func(t *testing.T) {
+		var output strings.Builder
+		log.SetOutput(&output)
+		t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+		filePath := filepath.Join(t.TempDir(), "file.txt")
+		if error := os.MkdirAll(filepath.Join(filePath, "child"), 0755); error != nil {
+			t.Fatal(error)
+		}
+		job := &fileJob{file: &drive.File{Id: "file", Name: "file.txt"}, localPath: filePath, replaceExisting: true}
+		if _, error := downloadFile(context.Background(), client, job, &statusTracker{}); error == nil {
+			t.Fatal("downloadFile succeeded, want error")
+		}
+		if !strings.Contains(output.String(), "rename '"+filePath+"'") {
+			t.Errorf("log output = %q, want rename error", output.String())
+		}
+	})
+}
+
+func TestConvertGoogleFileType(t *testing.T) {
+	dir := t.TempDir()
+	var exports atomic.Int32
+	var finalPath string
+	driveService := newTestDriveService(t, func(w http.ResponseWriter, r *http.Request) {
+		exports.Add(1)
+		if _, error := os.Stat(finalPath); error == nil {
+			http.Error(w, "final file exists before the export finished", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, r.URL.Query().Get("mimeType"))
+	})
+	client := newDriveClient(driveService, 0)
+
+	tests := []struct {
+		name      string
+		mimeType  string
+		extension string
+		want      string
+	}{
+		{name: "document", mimeType: "application/vnd.google-apps.document", extension: ".docx", want: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{name: "spreadsheet", mimeType: "application/vnd.google-apps.spreadsheet", extension: ".xlsx", want: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{name: "presentation", mimeType: "application/vnd.google-apps.presentation", extension: ".pptx", want: "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := filepath.Join(dir, test.name)
+			finalPath = filePath + test.extension
+			job := &fileJob{file: &drive.File{Id: test.name, Name: test.name, MimeType: test.mimeType}, localPath: filePath}
+			if _, error := convertGoogleFileType(context.Background(), client, job, &statusTracker{}); error != nil {
+				t.Fatalf("convertGoogleFileType: %v", error)
+			}
+			got, error := os.ReadFile(finalPath)
+			if error != nil {
+				t.Fatalf("read %s: %v", finalPath, error)
+			}
+			if string(got) != test.want {
+				t.Errorf("exported MIME type = %q, want %q", got, test.want)
+			}
+			if _, error := os.Stat(finalPath + ".tmp"); !os.IsNotExist(error) {
+				t.Errorf("temp file still exists: %v", error)
+			}
+		})
+	}
+
+	t.Run("unknown type", func(t *testing.T) {
+		exports.Store(0)
+		filePath := filepath.Join(t.TempDir(), "unknown")
+		job := &fileJob{file: &drive.File{Id: "unknown", Name: "unknown", MimeType: "application/vnd.google-apps.unknown"}, localPath: filePath}
+		if _, error := convertGoogleFileType(context.Background(), client, job, &statusTracker{}); error != nil {
+			t.Fatalf("convertGoogleFileType: %v", error)
+		}
+		if count := exports.Load(); count != 0 {
+			t.Errorf("exports = %d, want 0", count)
+		}
+		if entries, _ := os.ReadDir(filepath.Dir(filePath)); len(entries) != 0 {
+			t.Errorf("wrote %d files, want none", len(entries))
+		}
+	})
+
+	t.Run("existing export", func(t *testing.T) {
+		exports.Store(0)
+		filePath := filepath.Join(t.TempDir(), "existing")
+		if error := os.WriteFile(filePath+".docx", []byte("local"), 0644); error != nil {
+			t.Fatal(error)
+		}
+		tracker := statusTracker{}
+		job := &fileJob{file: &drive.File{Id: "existing", Name: "existing", MimeType: "application/vnd.google-apps.document"}, localPath: filePath}
+		if _, error := convertGoogleFileType(context.Background(), client, job, &tracker); error != nil {
+			t.Fatalf("convertGoogleFileType: %v", error)
+		}
+		if count := exports.Load(); count != 0 {
+			t.Errorf("exports = %d, want 0", count)
+		}
+		if skipped := tracker.skippedFiles.Load(); skipped != 1 {
+			t.Errorf("skippedFiles = %d, want 1", skipped)
+		}
+		if got, _ := os.ReadFile(filePath + ".docx"); string(got) != "local" {
+			t.Errorf("content = %q, want %q", got, "local")
+		}
+	})
+}
+
+func BenchmarkDiscoverAndQueueFiles(b *testing.B) {
+	const folders, filesPerFolder = 1000, 100
+	children := map[string][]*drive.File{}
+	for i := 0; i < folders; i++ {
+		folderID := fmt.Sprintf("folder-%d", i)
+		children["root"] = append(children["root"], &drive.File{Id: folderID, Name: folderID, MimeType: "application/vnd.google-apps.folder"})
+		for j := 0; j < filesPerFolder; j++ {
+			name := fmt.Sprintf("file-%d-%d.txt", i, j)
+			children[folderID] = append(children[folderID], &drive.File{Id: name, Name: name, MimeType: "text/plain", Size: 1024})
+		}
+	}
+	parent := regexp.MustCompile(`'([^']*)' in parents`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match := parent.FindStringSubmatch(r.URL.Query().Get("q"))
+		if match == nil {
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		files := children[match[1]]
+		start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		end := min(start+size, len(files))
+		list := &drive.FileList{Files: files[start:end]}
+		if end < len(files) {
+			list.NextPageToken = strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(list)
+	}))
+	defer server.Close()
+	driveService, error := NewDriveService(context.Background(), []option.ClientOption{option.WithEndpoint(server.URL + "/"), option.WithHTTPClient(server.Client())}, apiVersion)
+	if error != nil {
+		b.Fatalf("NewDriveService: %v", error)
+	}
+	client := newDriveClient(driveService, 0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		channelFileJob := make(chan *fileJob, fileJobQueueSize)
+		tracker := statusTracker{}
+		var discoveryWaitGroup sync.WaitGroup
+		discoveryWaitGroup.Add(1)
+		discoverAndQueueFiles(context.Background(), client, "root", b.TempDir(), channelFileJob, &discoveryWaitGroup, &tracker)
+		if found := tracker.totalFilesFound.Load(); found != folders*filesPerFolder {
+			b.Fatalf("totalFilesFound = %d, want %d", found, folders*filesPerFolder)
+		}
+	}
+	b.ReportMetric(float64(folders*filesPerFolder)*float64(b.N)/b.Elapsed().Seconds(), "files/s")
+}
+
+func TestDiscoverAndQueueFilesTrashed(t *testing.T) {
+	tests := []struct {
+		name      string
+		flag      *bool
+		wantQuery string
+		want      map[string]string
+	}{
+		{name: "trashed", flag: trashed, wantQuery: "'root' in parents", want: map[string]string{"live": "live.txt", "old": filepath.Join("_trash", "old.txt")}},
+		{name: "trashed only", flag: trashedOnly, wantQuery: "'root' in parents and (trashed=true or mimeType='application/vnd.google-apps.folder')", want: map[string]string{"old": filepath.Join("_trash", "old.txt")}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var query string
+			driveService := newTestDriveService(t, func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.Query().Get("q")
+				if strings.Contains(query, "trashed=true") {
+					fmt.Fprint(w, `{"files": [{"id": "old", "name": "old.txt", "trashed": true}]}`)
+					return
+				}
+				fmt.Fprint(w, `{"files": [{"id": "live", "name": "live.txt"}, {"id": "old", "name": "old.txt", "trashed": true}]}`)
+			})
+			*test.flag = true
+			t.Cleanup(func() { *test.flag = false })
+
+			dir := t.TempDir()
+			channelFileJob := make(chan *fileJob, 10)
+			var discoveryWaitGroup sync.WaitGroup
+			discoveryWaitGroup.Add(1)
+			discoverAndQueueFiles(context.Background(), newDriveClient(driveService, 0), "root", dir, channelFileJob, &discoveryWaitGroup, &statusTracker{})
+			close(channelFileJob)
+
+			if query != test.wantQuery {
+				t.Errorf("query = %q, want %q", query, test.wantQuery)
+			}
+			for job := range channelFileJob {
+				want, ok := test.want[job.file.Id]
+				if !ok {
+					t.Errorf("unexpected file queued: %s", job.file.Id)
+					continue
+				}
+				if want = filepath.Join(dir, want); job.localPath != want {
+					t.Errorf("%s localPath = %q, want %q", job.file.Id, job.localPath, want)
+				}
+				delete(test.want, job.file.Id)
+			}
+			if len(test.want) != 0 {
+				t.Errorf("files not queued: %v", test.want)
+			}
+		})
+	}
+}
+
+func TestResolveConflictWithoutMD5(t *testing.T) {
+	*conflictStrategy = "overwrite"
+	t.Cleanup(func() { *conflictStrategy = "skip" })
+	filePath := filepath.Join(t.TempDir(), "doc.docx")
+	if error := os.WriteFile(filePath, []byte("exported"), 0644); error != nil {
+		t.Fatal(error)
+	}
+	exportedAt := time.Now().Add(-time.Hour)
+	if error := os.Chtimes(filePath, exportedAt, exportedAt); error != nil {
+		t.Fatal(error)
+	}
+
+	unchanged := &drive.File{Name: "doc", ModifiedTime: exportedAt.Add(-time.Hour).Format(time.RFC3339)}
+	if got := resolveConflict(unchanged, filePath); got != "" {
+		t.Errorf("resolveConflict(unchanged) = %q, want skip", got)
+	}
+	edited := &drive.File{Name: "doc", ModifiedTime: exportedAt.Add(time.Minute).Format(time.RFC3339)}
+	if got := resolveConflict(edited, filePath); got != filePath {
+		t.Errorf("resolveConflict(edited) = %q, want %q", got, filePath)
+	}
+}
+
+func TestFlatNamesAreDeterministic(t *testing.T) {
+	*flat = true
+	t.Cleanup(func() { *flat = false })
+	for _, order := range []string{
+		`[{"id": "a", "name": "report.pdf"}, {"id": "b", "name": "report.pdf"}]`,
+		`[{"id": "b", "name": "report.pdf"}, {"id": "a", "name": "report.pdf"}]`,
+	} {
+		flatNames = map[string]string{}
+		driveService := newTestDriveService(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"files": %s}`, order)
+		})
+		dir := t.TempDir()
+		channelFileJob := make(chan *fileJob, 10)
+		var discoveryWaitGroup sync.WaitGroup
+		discoveryWaitGroup.Add(1)
+		discoverAndQueueFiles(context.Background(), newDriveClient(driveService, 0), "root", dir, channelFileJob, &discoveryWaitGroup, &statusTracker{})
+		close(channelFileJob)
+
+		want := map[string]string{"a": "report.pdf", "b": "root_report.pdf"}
+		for job := range channelFileJob {
+			if got := filepath.Base(job.localPath); got != want[job.file.Id] {
+				t.Errorf("order %s: %s name = %q, want %q", order, job.file.Id, got, want[job.file.Id])
+			}
+		}
+	}
+}
+
+func TestSearchFolders(t *testing.T) {
+	children := map[string]string{
+		"src":  `[{"id": "docs", "name": "docs"}, {"id": "pics", "name": "pics"}]`,
+		"docs": `[{"id": "old", "name": "old"}]`,
+	}
+	parent := regexp.MustCompile(`'([^']*)' in parents`)
+	driveService := newTestDriveService(t, func(w http.ResponseWriter, r *http.Request) {
+		match := parent.FindStringSubmatch(r.URL.Query().Get("q"))
+		if match == nil {
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		files := children[match[1]]
+		if files == "" {
+			files = "[]"
+		}
+		fmt.Fprintf(w, `{"files": %s}`, files)
+	})
+
+	folderIDs, folderPaths, error := searchFolders(context.Background(), newDriveClient(driveService, 0), "src", "/src")
+	if error != nil {
+		t.Fatalf("searchFolders: %v", error)
+	}
+	if want := []string{"src", "docs", "pics", "old"}; !slices.Equal(folderIDs, want) {
+		t.Errorf("folderIDs = %v, want %v", folderIDs, want)
+	}
+	if got, want := folderPaths["old"], filepath.Join("/src", "docs", "old"); got != want {
+		t.Errorf("folderPaths[old] = %q, want %q", got, want)
+	}
+}
+
+func TestRememberExistingFileChecksMD5(t *testing.T) {
+	*dedupHardlinks = true
+	t.Cleanup(func() { *dedupHardlinks = false })
+	downloadedByMD5 = map[string]string{}
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "stale.txt")
+	if error := os.WriteFile(stale, []byte("old"), 0644); error != nil {
+		t.Fatal(error)
+	}
+	sum := md5.Sum([]byte("new"))
+	file := &drive.File{Name: "stale.txt", Md5Checksum: hex.EncodeToString(sum[:])}
+
+	rememberExistingFile(file, stale)
+	if path, ok := downloadedByMD5[file.Md5Checksum]; ok {
+		t.Fatalf("stale file remembered as %q", path)
+	}
+
+	current := filepath.Join(dir, "current.txt")
+	if error := os.WriteFile(current, []byte("new"), 0644); error != nil {
+		t.Fatal(error)
+	}
+	rememberExistingFile(file, current)
+	if got := downloadedByMD5[file.Md5Checksum]; got != current {
+		t.Errorf("remembered %q, want %q", got, current)
+	}
+}
+
+func TestBackoffCeilingDoesNotOverflow(t *testing.T) {
+	for _, attempt := range []int{0, 1, 10, 33, 34, 63, 64, 1000} {
+		if got := backoffCeiling(attempt); got <= 0 || got > maxRetryDelay {
+			t.Errorf("backoffCeiling(%d) = %s, want between 0 and %s", attempt, got, maxRetryDelay)
+		}
+	}
+}
+
+func TestDownloadFileChunkedFallsBackWithoutRange(t *testing.T) {
+	const content = "range requests are not supported here"
+	driveService := newTestDriveService(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, content)
+	})
+	sum := md5.Sum([]byte(content))
+	file := &drive.File{Id: "file", Name: "file", Size: int64(len(content)), Md5Checksum: hex.EncodeToString(sum[:])}
+	tempFilePath := filepath.Join(t.TempDir(), "file.tmp")
+
+	if _, error := downloadFileChunked(context.Background(), newDriveClient(driveService, 0), file, tempFilePath); error != nil {
+		t.Fatalf("downloadFileChunked: %v", error)
+	}
+	got, error := os.ReadFile(tempFilePath)
+	if error != nil {
+		t.Fatalf("read: %v", error)
+	}
+	if string(got) != content {
+		t.Errorf("content = %q, want %q", got, content)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func runMv(args []string) {
+	flagSet := newCommandFlagSet("mv")
+	fileID := flagSet.String("file-id", "", "ID do arquivo no Drive a ser movido")
+	destFolderID := flagSet.String("dest-folder-id", "", "ID da pasta do Drive para onde o arquivo será movido")
+	name := flagSet.String("name", "", "novo nome do arquivo")
+	parseFlags(flagSet, args)
+	if *fileID == "" || (*destFolderID == "" && *name == "") {
+		fmt.Fprintln(os.Stderr, "Uso: godrive mv --file-id ID [--dest-folder-id ID] [--name novo-nome]")
+		os.Exit(2)
+	}
+
+	driveService := authenticateWithScope(context.Background(), drive.DriveScope)
+	file, error := driveService.Files.Get(*fileID).SupportsAllDrives(true).Fields("id, name, parents").Do()
+	if error != nil {
+		fatalf("Não foi possível encontrar o arquivo '%s': %s", *fileID, formatAPIError(error))
+	}
+
+	call := driveService.Files.Update(file.Id, &drive.File{Name: *name}).SupportsAllDrives(true).Fields("id, name, parents")
+	if *destFolderID != "" {
+		call = call.AddParents(*destFolderID).RemoveParents(strings.Join(file.Parents, ","))
+	}
+	moved, error := call.Do()
+	if error != nil {
+		errorLog.Printf("move '%s' (%s): %s", file.Name, file.Id, formatAPIError(error))
+		fatalf("Não foi possível mover '%s': %s", file.Name, formatAPIError(error))
+	}
+	log.Printf("movido '%s' para '%s' (%s)", file.Name, moved.Name, strings.Join(moved.Parents, ","))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var nomedia = flag.Bool("nomedia", false, "ao final, cria um arquivo .nomedia nas pastas que contêm apenas imagens e vídeos")
+
+var (
+	mediaDirectoriesMutex sync.Mutex
+	mediaDirectories      = map[string]bool{}
+)
+
+func recordMediaDirectory(job *fileJob) {
+	if !*nomedia {
+		return
+	}
+	isMedia := strings.HasPrefix(job.file.MimeType, "image/") || strings.HasPrefix(job.file.MimeType, "video/")
+	directory := filepath.Dir(job.localPath)
+	mediaDirectoriesMutex.Lock()
+	defer mediaDirectoriesMutex.Unlock()
+	if onlyMedia, ok := mediaDirectories[directory]; !ok || onlyMedia {
+		mediaDirectories[directory] = isMedia
+	}
+}
+
+func writeNomediaFiles() {
+	mediaDirectoriesMutex.Lock()
+	defer mediaDirectoriesMutex.Unlock()
+	for directory, onlyMedia := range mediaDirectories {
+		if !onlyMedia {
+			continue
+		}
+		path := filepath.Join(directory, ".nomedia")
+		if _, error := os.Stat(path); error == nil {
+			continue
+		}
+		if error := os.WriteFile(path, nil, 0644); error != nil {
+			errorLog.Printf("create '%s': %v", path, error)
+		}
+	}
+	clear(mediaDirectories)
+}
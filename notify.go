@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	smtpHost     = flag.String("smtp-host", "", "servidor SMTP usado para enviar um e-mail com o resumo ao final de cada sincronização")
+	smtpPort     = flag.Int("smtp-port", 587, "porta do servidor SMTP")
+	smtpFrom     = flag.String("smtp-from", "", "remetente do e-mail de resumo (também usado como usuário SMTP; a senha vem de GODRIVE_SMTP_PASSWORD)")
+	smtpTo       = flag.String("smtp-to", "", "destinatários do e-mail de resumo, separados por vírgula")
+	smtpSubject  = flag.String("smtp-subject", "Resumo do godrive", "assunto do e-mail de resumo")
+	smtpTLS      = flag.Bool("smtp-tls", false, "conecta ao servidor SMTP usando TLS direto (normalmente na porta 465)")
+	smtpStartTLS = flag.Bool("smtp-starttls", false, "usa STARTTLS após conectar ao servidor SMTP (normalmente na porta 587)")
+	notifyOn     = flag.String("notify-on", "always", "quando enviar o e-mail de resumo: success, failure ou always")
+)
+
+const notificationTimeout = 30 * time.Second
+
+var notificationSent bool
+
+func validateNotifications() {
+	if *smtpHost == "" {
+		return
+	}
+	if *smtpFrom == "" || *smtpTo == "" {
+		fatalf("--smtp-host exige --smtp-from e --smtp-to")
+	}
+	if *smtpTLS && *smtpStartTLS {
+		fatalf("Use apenas um entre --smtp-tls e --smtp-starttls")
+	}
+	if *notifyOn != "success" && *notifyOn != "failure" && *notifyOn != "always" {
+		fatalf("Valor inválido para --notify-on: '%s' (use success, failure ou always)", *notifyOn)
+	}
+}
+
+func sendNotification(statusTracker *statusTracker, success bool) {
+	if *smtpHost == "" || (*notifyOn == "success" && !success) || (*notifyOn == "failure" && success) || !shouldNotify(&notificationSent, success) {
+		return
+	}
+	recipients := strings.Split(*smtpTo, ",")
+	for index := range recipients {
+		recipients[index] = strings.TrimSpace(recipients[index])
+	}
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", *smtpFrom)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", *smtpSubject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(notificationBody(statusTracker.summary(), success), "\n", "\r\n"))
+	if error := sendMail(recipients, message.String()); error != nil {
+		errorLog.Printf("send notification e-mail: %v", error)
+	}
+}
+
+func shouldNotify(sent *bool, success bool) bool {
+	if *sent && success {
+		return false
+	}
+	*sent = true
+	return true
+}
+
+func notificationBody(summary reportSummary, success bool) string {
+	var body strings.Builder
+	if success {
+		body.WriteString("Sincronização concluída com sucesso.\n\n")
+	} else {
+		body.WriteString("Sincronização concluída com falhas.\n\n")
+	}
+	fmt.Fprintf(&body, "Data: %s\n", summary.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&body, "Duração: %s\n", summary.Duration)
+	fmt.Fprintf(&body, "Arquivos encontrados: %d\n", summary.Total)
+	fmt.Fprintf(&body, "Concluídos: %d\n", summary.Completed)
+	fmt.Fprintf(&body, "Pulados: %d\n", summary.Skipped)
+	fmt.Fprintf(&body, "Acesso negado: %d\n", summary.AccessDenied)
+	fmt.Fprintf(&body, "Exportação restrita: %d\n", summary.Restricted)
+	fmt.Fprintf(&body, "Falhas: %d\n", summary.Failed)
+	fmt.Fprintf(&body, "Vazão: %.2f arquivos/s\n", summary.Throughput)
+	if len(summary.Failures) > 0 {
+		body.WriteString("\nArquivos com falha:\n")
+		for _, failure := range summary.Failures {
+			fmt.Fprintf(&body, "- %s: %s\n", failure.Path, failure.Reason)
+		}
+	}
+	return body.String()
+}
+
+func sendMail(recipients []string, message string) error {
+	address := net.JoinHostPort(*smtpHost, strconv.Itoa(*smtpPort))
+	tlsConfig := &tls.Config{ServerName: *smtpHost}
+	dialer := &net.Dialer{Timeout: notificationTimeout}
+	var connection net.Conn
+	var error error
+	if *smtpTLS {
+		connection, error = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		connection, error = dialer.Dial("tcp", address)
+	}
+	if error != nil {
+		return error
+	}
+	connection.SetDeadline(time.Now().Add(notificationTimeout))
+	client, error := smtp.NewClient(connection, *smtpHost)
+	if error != nil {
+		connection.Close()
+		return error
+	}
+	defer client.Close()
+	if *smtpStartTLS {
+		if error := client.StartTLS(tlsConfig); error != nil {
+			return error
+		}
+	}
+
+	if password := os.Getenv("GODRIVE_SMTP_PASSWORD"); password != "" {
+		if error := client.Auth(smtp.PlainAuth("", *smtpFrom, password, *smtpHost)); error != nil {
+			return error
+		}
+	}
+	if error := client.Mail(*smtpFrom); error != nil {
+		return error
+	}
+	for _, recipient := range recipients {
+		if error := client.Rcpt(recipient); error != nil {
+			return error
+		}
+	}
+	writer, error := client.Data()
+	if error != nil {
+		return error
+	}
+	if _, error := writer.Write([]byte(message)); error != nil {
+		return error
+	}
+	if error := writer.Close(); error != nil {
+		return error
+	}
+	return client.Quit()
+}
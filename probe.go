@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"sync/atomic"
+)
+
+var (
+	probeAddr = flag.String("probe-addr", "", "endereço do servidor HTTP com /healthz e /readyz para probes do Kubernetes, por exemplo :8081")
+	maxErrors = flag.Int("max-errors", 0, "quantidade de arquivos com falha a partir da qual /healthz passa a retornar 500 (0 para ilimitado)")
+)
+
+var probes struct {
+	ready       atomic.Bool
+	errors      atomic.Int32
+	runFinished atomic.Bool
+	runClean    atomic.Bool
+}
+
+func startProbeServer() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !probes.ready.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !healthy() {
+			http.Error(w, "unhealthy", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	go func() {
+		if error := http.ListenAndServe(*probeAddr, mux); error != nil {
+			fatalf("Não foi possível iniciar o servidor de probes em '%s': %v", *probeAddr, error)
+		}
+	}()
+}
+
+func healthy() bool {
+	if probes.runFinished.Load() {
+		return probes.runClean.Load()
+	}
+	return *maxErrors == 0 || int(probes.errors.Load()) < *maxErrors
+}
+
+func finishProbeRun(clean bool) {
+	probes.runClean.Store(clean)
+	probes.runFinished.Store(true)
+}
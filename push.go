@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+)
+
+var (
+	pushNotifications = flag.Bool("push-notifications", false, "no modo --watch, sincroniza ao receber notificações push do Drive em vez de consultar periodicamente")
+	webhookListenAddr = flag.String("webhook-listen-addr", ":8080", "endereço local onde as notificações push do Drive são recebidas")
+	webhookURL        = flag.String("webhook-url", "", "URL HTTPS pública que encaminha para --webhook-listen-addr, registrada no Drive")
+)
+
+const (
+	watchChannelLifetime = 24 * time.Hour
+	watchChannelRenewal  = time.Hour
+)
+
+func watchPushNotifications(ctx context.Context, client *driveClient, folderID, pageToken string) {
+	if *webhookURL == "" {
+		fatal("O modo --push-notifications exige --webhook-url")
+	}
+
+	notifications := make(chan struct{}, 1)
+	var currentChannel atomic.Pointer[drive.Channel]
+	server := &http.Server{Addr: *webhookListenAddr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		channel := currentChannel.Load()
+		if channel == nil || r.Header.Get("X-Goog-Channel-ID") != channel.Id || r.Header.Get("X-Goog-Resource-State") == "sync" {
+			return
+		}
+		select {
+		case notifications <- struct{}{}:
+		default:
+		}
+	})}
+	go func() {
+		if error := server.ListenAndServe(); error != nil && error != http.ErrServerClosed {
+			fatalf("Não foi possível escutar notificações em '%s': %v", *webhookListenAddr, error)
+		}
+	}()
+	defer server.Close()
+
+	rescan := make(chan os.Signal, 1)
+	notifyRescan(rescan)
+
+	channel := registerWatchChannel(client.service, pageToken)
+	currentChannel.Store(channel)
+	defer func() { stopWatchChannel(client.service, currentChannel.Load()) }()
+
+	resolver := newFolderPathResolver(ctx, client, folderID, *destinationPath)
+	renewAt := channelRenewalTime(channel)
+	for {
+		fmt.Printf("\rObservando (aguardando notificações do Drive, canal renovado em %s)          ", renewAt.Format(time.TimeOnly))
+		select {
+		case <-ctx.Done():
+			return
+		case <-notifications:
+			fmt.Println()
+			pageToken = syncChanges(ctx, client, resolver, pageToken)
+		case <-rescan:
+			fmt.Println("\nSinal recebido, sincronizando agora...")
+			pageToken = syncChanges(ctx, client, resolver, pageToken)
+		case <-time.After(time.Until(renewAt)):
+			renewed := registerWatchChannel(client.service, pageToken)
+			stopWatchChannel(client.service, channel)
+			channel = renewed
+			currentChannel.Store(channel)
+			renewAt = channelRenewalTime(channel)
+		}
+	}
+}
+
+func channelRenewalTime(channel *drive.Channel) time.Time {
+	lifetime := time.Until(time.UnixMilli(channel.Expiration))
+	return time.Now().Add(max(lifetime-watchChannelRenewal, lifetime/2, time.Minute))
+}
+
+func registerWatchChannel(driveService *drive.Service, pageToken string) *drive.Channel {
+	channel, error := driveService.Changes.Watch(pageToken, &drive.Channel{
+		Id:         uuid.NewString(),
+		Type:       "web_hook",
+		Address:    *webhookURL,
+		Expiration: time.Now().Add(watchChannelLifetime).UnixMilli(),
+	}).IncludeItemsFromAllDrives(true).SupportsAllDrives(true).Do()
+	if error != nil {
+		fatalf("Não foi possível registrar o canal de notificações no Drive: %v", error)
+	}
+	return channel
+}
+
+func stopWatchChannel(driveService *drive.Service, channel *drive.Channel) {
+	if error := driveService.Channels.Stop(&drive.Channel{Id: channel.Id, ResourceId: channel.ResourceId}).Do(); error != nil {
+		errorLog.Printf("stop watch channel '%s': %v", channel.Id, error)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func runQuota(args []string) {
+	parseFlags(newCommandFlagSet("quota"), args)
+	driveService := authenticate(context.Background())
+
+	about, error := driveService.About.Get().Fields("storageQuota").Do()
+	if error != nil {
+		fatalf("Não foi possível consultar a cota de armazenamento: %v", error)
+	}
+	quota := about.StorageQuota
+
+	fmt.Printf("Usado:     %8.2f GB\n", gigabytes(uint64(quota.Usage)))
+	if quota.Limit == 0 {
+		fmt.Println("Limite:    ilimitado")
+	} else {
+		fmt.Printf("Limite:    %8.2f GB\n", gigabytes(uint64(quota.Limit)))
+		fmt.Printf("Restante:  %8.2f GB\n", gigabytes(uint64(max(quota.Limit-quota.Usage, 0))))
+	}
+	fmt.Println()
+	fmt.Printf("Drive:           %8.2f GB (lixeira: %.2f GB)\n", gigabytes(uint64(quota.UsageInDrive)), gigabytes(uint64(quota.UsageInDriveTrash)))
+	fmt.Printf("Gmail e Fotos:   %8.2f GB\n", gigabytes(uint64(max(quota.Usage-quota.UsageInDrive, 0))))
+}
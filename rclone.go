@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+var (
+	rcloneConfig = flag.String("rclone-config", "", "usa as credenciais de um remote do rclone em vez de credentials.json, ex.: ~/.config/rclone/rclone.conf")
+	rcloneRemote = flag.String("rclone-remote", "", "nome do remote do Google Drive no arquivo do --rclone-config")
+)
+
+func rcloneCredentials(scopes []string) (*oauth2.Config, *oauth2.Token, error) {
+	path := *rcloneConfig
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, error := os.UserHomeDir()
+		if error != nil {
+			return nil, nil, error
+		}
+		path = filepath.Join(home, rest)
+	}
+	section, error := readINISection(path, *rcloneRemote)
+	if error != nil {
+		return nil, nil, error
+	}
+	if section["type"] != "drive" {
+		return nil, nil, fmt.Errorf("o remote '%s' não é do tipo drive", *rcloneRemote)
+	}
+	if section["client_id"] == "" || section["client_secret"] == "" {
+		return nil, nil, fmt.Errorf("o remote '%s' não tem client_id e client_secret próprios", *rcloneRemote)
+	}
+	token := &oauth2.Token{}
+	if error := json.Unmarshal([]byte(section["token"]), token); error != nil {
+		return nil, nil, fmt.Errorf("token inválido no remote '%s': %v", *rcloneRemote, error)
+	}
+	config := &oauth2.Config{
+		ClientID:     section["client_id"],
+		ClientSecret: section["client_secret"],
+		Endpoint:     google.Endpoint,
+		Scopes:       scopes,
+	}
+	return config, token, nil
+}
+
+func readINISection(path, name string) (map[string]string, error) {
+	file, error := os.Open(path)
+	if error != nil {
+		return nil, error
+	}
+	defer file.Close()
+
+	var section map[string]string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			if section != nil {
+				break
+			}
+			if strings.TrimSpace(line[1:len(line)-1]) == name {
+				section = map[string]string{}
+			}
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && section != nil {
+			section[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if error := scanner.Err(); error != nil {
+		return nil, error
+	}
+	if section == nil {
+		return nil, fmt.Errorf("o remote '%s' não foi encontrado em '%s'", name, path)
+	}
+	return section, nil
+}
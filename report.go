@@ -0,0 +1,101 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"os"
+	"time"
+)
+
+//go:embed report.html.tmpl
+var reportTemplates embed.FS
+
+var reportTemplate = template.Must(template.ParseFS(reportTemplates, "report.html.tmpl"))
+
+type reportEntry struct {
+	FileID string
+	Path   string
+	Reason string
+}
+
+type reportSummary struct {
+	GeneratedAt  time.Time
+	Duration     time.Duration
+	Total        int32
+	Completed    int32
+	Skipped      int32
+	AccessDenied int32
+	Restricted   int32
+	Failed       int32
+	Throughput   float64
+	Skips        []reportEntry
+	Failures     []reportEntry
+}
+
+func (statusTracker *statusTracker) skip(job *fileJob, reason string) {
+	statusTracker.skippedFiles.Add(1)
+	recordState(skippedBucket, job, reason)
+	statusTracker.recordEntry(&statusTracker.skippedEntries, job, reason)
+}
+
+func (statusTracker *statusTracker) deny(job *fileJob, error error) {
+	statusTracker.accessDenied.Add(1)
+	statusTracker.recordEntry(&statusTracker.skippedEntries, job, "acesso negado: "+error.Error())
+}
+
+func (statusTracker *statusTracker) restrict(job *fileJob, error error) {
+	statusTracker.restrictedFiles.Add(1)
+	statusTracker.recordEntry(&statusTracker.skippedEntries, job, "exportação restrita: "+error.Error())
+}
+
+func (statusTracker *statusTracker) fail(job *fileJob, error error) {
+	statusTracker.failedFiles.Add(1)
+	probes.errors.Add(1)
+	statusTracker.recordEntry(&statusTracker.failedEntries, job, error.Error())
+}
+
+func (statusTracker *statusTracker) recordEntry(entries *[]reportEntry, job *fileJob, reason string) {
+	if !*htmlReport && *smtpHost == "" {
+		return
+	}
+	statusTracker.reportMutex.Lock()
+	defer statusTracker.reportMutex.Unlock()
+	*entries = append(*entries, reportEntry{FileID: job.file.Id, Path: job.localPath, Reason: reason})
+}
+
+func (statusTracker *statusTracker) summary() reportSummary {
+	duration := time.Since(statusTracker.startTime)
+	completed := statusTracker.completedFiles.Load()
+	statusTracker.reportMutex.Lock()
+	defer statusTracker.reportMutex.Unlock()
+	return reportSummary{
+		GeneratedAt:  time.Now(),
+		Duration:     duration.Round(time.Second),
+		Total:        statusTracker.totalFilesFound.Load(),
+		Completed:    completed,
+		Skipped:      statusTracker.skippedFiles.Load(),
+		AccessDenied: statusTracker.accessDenied.Load(),
+		Restricted:   statusTracker.restrictedFiles.Load(),
+		Failed:       statusTracker.failedFiles.Load(),
+		Throughput:   float64(completed) / duration.Seconds(),
+		Skips:        statusTracker.skippedEntries,
+		Failures:     statusTracker.failedEntries,
+	}
+}
+
+func writeHTMLReport(statusTracker *statusTracker) {
+	summary := statusTracker.summary()
+	path := fmt.Sprintf("godrive_report_%s.html", summary.GeneratedAt.Format("20060102-150405"))
+	out, error := os.Create(path)
+	if error != nil {
+		errorLog.Printf("create report '%s': %v", path, error)
+		return
+	}
+	defer out.Close()
+	if error := reportTemplate.Execute(out, summary); error != nil {
+		errorLog.Printf("write report '%s': %v", path, error)
+		return
+	}
+	fmt.Printf("Relatório salvo em: %s\n", path)
+}
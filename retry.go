@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	baseRetryDelay = time.Second
+	maxRetryDelay  = 30 * time.Second
+)
+
+var backoffJitter = flag.String("backoff-jitter", "none", "aleatoriedade no intervalo entre tentativas: full, equal, decorrelated ou none")
+
+type JitterStrategy interface {
+	Delay(attempt int, previous time.Duration) time.Duration
+}
+
+type noJitter struct{}
+
+type fullJitter struct{}
+
+type equalJitter struct{}
+
+type decorrelatedJitter struct{}
+
+var jitterStrategies = map[string]JitterStrategy{
+	"none":         noJitter{},
+	"full":         fullJitter{},
+	"equal":        equalJitter{},
+	"decorrelated": decorrelatedJitter{},
+}
+
+var retryJitter JitterStrategy = noJitter{}
+
+func backoffCeiling(attempt int) time.Duration {
+	if baseRetryDelay > maxRetryDelay>>attempt {
+		return maxRetryDelay
+	}
+	return baseRetryDelay << attempt
+}
+
+func (noJitter) Delay(attempt int, previous time.Duration) time.Duration {
+	return backoffCeiling(attempt)
+}
+
+func (fullJitter) Delay(attempt int, previous time.Duration) time.Duration {
+	return time.Duration(rand.Float64() * float64(backoffCeiling(attempt)))
+}
+
+func (equalJitter) Delay(attempt int, previous time.Duration) time.Duration {
+	ceiling := backoffCeiling(attempt)
+	return ceiling/2 + time.Duration(rand.Float64()*float64(ceiling/2))
+}
+
+func (decorrelatedJitter) Delay(attempt int, previous time.Duration) time.Duration {
+	previous = max(previous, baseRetryDelay)
+	return min(baseRetryDelay+time.Duration(rand.Float64()*float64(previous*3-baseRetryDelay)), maxRetryDelay)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func runRm(args []string) {
+	flagSet := newCommandFlagSet("rm")
+	fileID := flagSet.String("file-id", "", "ID do arquivo no Drive")
+	filePath := flagSet.String("path", "", "caminho do arquivo no Drive, ex.: pasta/arquivo.pdf")
+	permanent := flagSet.Bool("permanent", false, "apaga o arquivo definitivamente em vez de movê-lo para a lixeira")
+	yes := flagSet.Bool("yes", false, "não pede confirmação")
+	parseFlags(flagSet, args)
+	if (*fileID == "") == (*filePath == "") {
+		fmt.Fprintln(os.Stderr, "Uso: godrive rm (--file-id ID | --path pasta/arquivo) [--permanent] [--yes]")
+		os.Exit(2)
+	}
+
+	driveService := authenticateWithScope(context.Background(), drive.DriveScope)
+	if *filePath != "" {
+		var error error
+		*fileID, error = getDriveFileIDByPath(driveService, *filePath)
+		if error != nil {
+			fatalf("ERRO: %v", error)
+		}
+	}
+	file, error := driveService.Files.Get(*fileID).SupportsAllDrives(true).Fields("id, name").Do()
+	if error != nil {
+		fatalf("Não foi possível encontrar o arquivo '%s': %v", *fileID, error)
+	}
+
+	action := "Mover para a lixeira"
+	if *permanent {
+		action = "Apagar definitivamente"
+	}
+	if !*yes && !confirm(fmt.Sprintf("%s '%s' (%s)?", action, file.Name, file.Id)) {
+		fmt.Println("Cancelado.")
+		return
+	}
+
+	if *permanent {
+		error = driveService.Files.Delete(file.Id).SupportsAllDrives(true).Do()
+	} else {
+		_, error = driveService.Files.Update(file.Id, &drive.File{Trashed: true}).SupportsAllDrives(true).Do()
+	}
+	if error != nil {
+		errorLog.Printf("rm '%s' (%s): %s", file.Name, file.Id, formatAPIError(error))
+		fatalf("Não foi possível remover '%s': %v", file.Name, error)
+	}
+	if *permanent {
+		log.Printf("apagado definitivamente '%s' (%s)", file.Name, file.Id)
+	} else {
+		log.Printf("movido para a lixeira '%s' (%s)", file.Name, file.Id)
+	}
+}
+
+func getDriveFileIDByPath(driveService *drive.Service, filePath string) (string, error) {
+	folderPath, name := path.Split(strings.Trim(filePath, "/"))
+	folderID, error := getDriveFolderIDByPath(driveService, folderPath)
+	if error != nil {
+		return "", error
+	}
+	query := fmt.Sprintf("name='%s' and '%s' in parents and trashed=false", escapeQuery(name), folderID)
+	fileList, error := listFiles(driveService).Q(query).Fields("files(id)").PageSize(2).Do()
+	if error != nil {
+		return "", fmt.Errorf("falha ao buscar pelo arquivo '%s': %v", filePath, error)
+	}
+	if len(fileList.Files) == 0 {
+		return "", fmt.Errorf("o arquivo '%s' não foi encontrado", filePath)
+	}
+	if len(fileList.Files) > 1 {
+		return "", fmt.Errorf("existe mais de um arquivo '%s', use --file-id", filePath)
+	}
+	return fileList.Files[0].Id, nil
+}
+
+func confirm(question string) bool {
+	fmt.Printf("%s [s/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "s" || answer == "sim" || answer == "y" || answer == "yes"
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const searchParentsPerQuery = 50
+
+func runSearch(args []string) {
+	flagSet := newCommandFlagSet("search")
+	query := flagSet.String("query", "", "texto a ser buscado no conteúdo e nos nomes dos arquivos")
+	limit := flagSet.Int("limit", 0, "quantidade máxima de resultados (0 para todos)")
+	parseFlags(flagSet, args)
+	if *query == "" {
+		fmt.Fprintln(os.Stderr, "Uso: godrive search --query \"texto\" [--src pasta/no/drive] [--limit N]")
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	driveService := authenticate(ctx)
+	client := newDriveClient(driveService, *apiRequestsPerSecond)
+	folderID, error := getDriveFolderIDByPath(driveService, *sourcePath)
+	if error != nil {
+		fatalf("ERRO: %v", error)
+	}
+	folderIDs, folderPaths, error := searchFolders(ctx, client, folderID, filepath.Clean("/"+*sourcePath))
+	if error != nil {
+		fatalf("Não foi possível listar as pastas de '%s': %s", *sourcePath, formatAPIError(error))
+	}
+
+	found := 0
+	for start := 0; start < len(folderIDs); start += searchParentsPerQuery {
+		batch := folderIDs[start:min(start+searchParentsPerQuery, len(folderIDs))]
+		parents := make([]string, len(batch))
+		for i, id := range batch {
+			parents[i] = fmt.Sprintf("'%s' in parents", id)
+		}
+		driveQuery := fmt.Sprintf("fullText contains '%s' and trashed=false and (%s)", escapeQuery(*query), strings.Join(parents, " or "))
+		var pageToken string
+		for {
+			if error := client.wait(ctx); error != nil {
+				fatal(error)
+			}
+			fileList, error := listFiles(driveService).Q(driveQuery).PageSize(*pageSize).Fields("nextPageToken, files(id, name, parents)").PageToken(pageToken).Do()
+			client.done(error)
+			if error != nil {
+				fatalf("Não foi possível buscar por '%s': %v", *query, error)
+			}
+			for _, file := range fileList.Files {
+				for _, parent := range file.Parents {
+					folderPath, ok := folderPaths[parent]
+					if !ok {
+						continue
+					}
+					fmt.Printf("%s\t(%s)\n", filepath.Join(folderPath, sanitizeFileName(file.Name)), file.Id)
+					found++
+					if *limit > 0 && found >= *limit {
+						return
+					}
+					break
+				}
+			}
+			pageToken = fileList.NextPageToken
+			if pageToken == "" {
+				break
+			}
+		}
+	}
+	if found == 0 {
+		fmt.Printf("Nenhum arquivo encontrado para '%s'.\n", *query)
+	}
+}
+
+func searchFolders(ctx context.Context, client *driveClient, rootID, rootPath string) ([]string, map[string]string, error) {
+	if rootID == "root" {
+		root, error := client.service.Files.Get("root").SupportsAllDrives(true).Fields("id").Do()
+		if error != nil {
+			return nil, nil, error
+		}
+		rootID = root.Id
+	}
+	folderIDs := []string{rootID}
+	folderPaths := map[string]string{rootID: rootPath}
+	for next := 0; next < len(folderIDs); next++ {
+		parentID := folderIDs[next]
+		query := fmt.Sprintf("'%s' in parents and mimeType='application/vnd.google-apps.folder' and trashed=false", parentID)
+		var pageToken string
+		for {
+			if error := client.wait(ctx); error != nil {
+				return nil, nil, error
+			}
+			fileList, error := listFiles(client.service).Q(query).PageSize(*pageSize).Fields("nextPageToken, files(id, name)").PageToken(pageToken).Do()
+			client.done(error)
+			if error != nil {
+				return nil, nil, error
+			}
+			for _, folder := range fileList.Files {
+				if _, seen := folderPaths[folder.Id]; seen {
+					continue
+				}
+				folderIDs = append(folderIDs, folder.Id)
+				folderPaths[folder.Id] = filepath.Join(folderPaths[parentID], sanitizeFileName(folder.Name))
+			}
+			pageToken = fileList.NextPageToken
+			if pageToken == "" {
+				break
+			}
+		}
+	}
+	return folderIDs, folderPaths, nil
+}
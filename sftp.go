@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var (
+	destSFTP = flag.String("dest-sftp", "", "envia os arquivos baixados para um servidor SFTP em vez de --dest, ex.: sftp://usuario@servidor:/caminho/remoto")
+	sshKey   = flag.String("ssh-key", "", "chave privada SSH usada com --dest-sftp, ex.: ~/.ssh/id_rsa (sem ela, usa o agente em SSH_AUTH_SOCK)")
+)
+
+type sftpDestination struct {
+	connection *ssh.Client
+	client     *sftp.Client
+	root       string
+}
+
+func newSFTPDestination(rawURL string) (*sftpDestination, error) {
+	parsed, error := url.Parse(rawURL)
+	if error != nil {
+		return nil, error
+	}
+	if parsed.Scheme != "sftp" || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("use o formato sftp://usuario@servidor:/caminho/remoto")
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = "22"
+	}
+	authMethod, error := sshAuthMethod()
+	if error != nil {
+		return nil, error
+	}
+	home, _ := os.UserHomeDir()
+	hostKeyCallback, error := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if error != nil {
+		return nil, fmt.Errorf("não foi possível ler ~/.ssh/known_hosts: %v", error)
+	}
+	config := &ssh.ClientConfig{
+		User:            parsed.User.Username(),
+		Auth:            []ssh.AuthMethod{authMethod},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
+	}
+	connection, error := ssh.Dial("tcp", net.JoinHostPort(parsed.Hostname(), port), config)
+	if error != nil {
+		return nil, error
+	}
+	client, error := sftp.NewClient(connection)
+	if error != nil {
+		connection.Close()
+		return nil, error
+	}
+	root := parsed.Path
+	if root == "" {
+		root = "."
+	}
+	return &sftpDestination{connection: connection, client: client, root: root}, nil
+}
+
+func sshAuthMethod() (ssh.AuthMethod, error) {
+	if *sshKey != "" {
+		keyPath := *sshKey
+		if rest, ok := strings.CutPrefix(keyPath, "~/"); ok {
+			home, error := os.UserHomeDir()
+			if error != nil {
+				return nil, error
+			}
+			keyPath = filepath.Join(home, rest)
+		}
+		key, error := os.ReadFile(keyPath)
+		if error != nil {
+			return nil, error
+		}
+		signer, error := ssh.ParsePrivateKey(key)
+		if error != nil {
+			return nil, fmt.Errorf("chave SSH '%s' inválida: %v", keyPath, error)
+		}
+		return ssh.PublicKeys(signer), nil
+	}
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, fmt.Errorf("informe --ssh-key ou inicie um agente SSH (SSH_AUTH_SOCK)")
+	}
+	connection, error := net.Dial("unix", socket)
+	if error != nil {
+		return nil, fmt.Errorf("não foi possível conectar ao agente SSH: %v", error)
+	}
+	return ssh.PublicKeysCallback(agent.NewClient(connection).Signers), nil
+}
+
+func (destination *sftpDestination) remotePath(relativePath string) string {
+	return path.Join(destination.root, filepath.ToSlash(relativePath))
+}
+
+func (destination *sftpDestination) exists(relativePath string) (bool, error) {
+	_, error := destination.client.Stat(destination.remotePath(relativePath))
+	if os.IsNotExist(error) {
+		return false, nil
+	}
+	return error == nil, error
+}
+
+func (destination *sftpDestination) store(relativePath string, modifiedTime time.Time, localPath string) error {
+	remotePath := destination.remotePath(relativePath)
+	if error := destination.client.MkdirAll(path.Dir(remotePath)); error != nil {
+		return error
+	}
+	source, error := os.Open(localPath)
+	if error != nil {
+		return error
+	}
+	defer source.Close()
+	target, error := destination.client.Create(remotePath)
+	if error != nil {
+		return error
+	}
+	if _, error := io.Copy(target, source); error != nil {
+		target.Close()
+		return error
+	}
+	if error := target.Close(); error != nil {
+		return error
+	}
+	return destination.client.Chtimes(remotePath, modifiedTime, modifiedTime)
+}
+
+func (destination *sftpDestination) close() error {
+	destination.client.Close()
+	return destination.connection.Close()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+
+	"google.golang.org/api/drive/v3"
+)
+
+var allSharedDrives = flag.Bool("all-shared-drives", false, "também baixa todos os drives compartilhados acessíveis, cada um em --dest/<id do drive>/<nome do drive>")
+
+type syncRoot struct {
+	folderID     string
+	relativePath string
+}
+
+var extraSyncRoots []syncRoot
+
+func listSharedDriveRoots(driveService *drive.Service) []syncRoot {
+	var roots []syncRoot
+	var pageToken string
+	for {
+		driveList, error := driveService.Drives.List().PageSize(100).Fields("nextPageToken, drives(id, name)").PageToken(pageToken).Do()
+		if error != nil {
+			fatalf("Não foi possível listar os drives compartilhados: %v", error)
+		}
+		for _, sharedDrive := range driveList.Drives {
+			roots = append(roots, syncRoot{folderID: sharedDrive.Id, relativePath: filepath.Join(sharedDrive.Id, sanitizeFileName(sharedDrive.Name))})
+		}
+		pageToken = driveList.NextPageToken
+		if pageToken == "" {
+			return roots
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/option"
+)
+
+var (
+	exportComments    = flag.Bool("export-comments", false, "salva os comentários de cada arquivo baixado em <arquivo>.comments.json")
+	exportPermissions = flag.Bool("export-permissions", false, "salva as permissões de compartilhamento de cada arquivo baixado em <arquivo>.permissions.json")
+	exportActivity    = flag.Bool("export-activity", false, "salva o histórico de atividade (Drive Activity API) de cada arquivo baixado em <arquivo>.activity.json")
+	sidecarRPS        = flag.Float64("sidecar-rps", 5, "limite de requisições por segundo para buscar comentários, permissões e outros metadados extras (0 desativa o limite)")
+)
+
+var (
+	sidecarLimiter     *rate.Limiter
+	sidecarLimiterOnce sync.Once
+)
+
+var activityService *driveactivity.Service
+
+func exportSidecars(ctx context.Context, client *driveClient, file *drive.File, filePath string) {
+	if *exportComments {
+		exportFileComments(ctx, client, file, filePath)
+	}
+	if *exportPermissions {
+		exportFilePermissions(ctx, client, file, filePath)
+	}
+	if *exportActivity {
+		exportFileActivity(ctx, client, file, filePath)
+	}
+	if *exportLabels {
+		exportFileLabels(ctx, client, file, filePath)
+	}
+}
+
+func newActivityService(ctx context.Context, client *http.Client) *driveactivity.Service {
+	service, error := driveactivity.NewService(ctx, option.WithHTTPClient(client))
+	if error != nil {
+		fatalf("Não foi possível criar o serviço da Drive Activity API: %v", error)
+	}
+	return service
+}
+
+func waitSidecar(ctx context.Context, client *driveClient) error {
+	sidecarLimiterOnce.Do(func() {
+		sidecarLimiter = rate.NewLimiter(rate.Inf, 0)
+		if *sidecarRPS > 0 {
+			sidecarLimiter = rate.NewLimiter(rate.Limit(*sidecarRPS), int(math.Max(1, *sidecarRPS)))
+		}
+	})
+	if error := client.breaker.allow(); error != nil {
+		return error
+	}
+	if error := sidecarLimiter.Wait(ctx); error != nil {
+		client.breaker.record(error)
+		return error
+	}
+	return nil
+}
+
+func exportFileComments(ctx context.Context, client *driveClient, file *drive.File, filePath string) {
+	var comments []*drive.Comment
+	var pageToken string
+	for {
+		if error := waitSidecar(ctx, client); error != nil {
+			errorLog.Printf("list comments of '%s': %s", file.Name, formatAPIError(error))
+			return
+		}
+		commentList, error := client.service.Comments.List(file.Id).PageSize(100).PageToken(pageToken).Fields("nextPageToken, comments(id,author,content,modifiedTime,replies)").Context(ctx).Do()
+		client.done(error)
+		if error != nil {
+			errorLog.Printf("list comments of '%s': %s", file.Name, formatAPIError(error))
+			return
+		}
+		comments = append(comments, commentList.Comments...)
+		pageToken = commentList.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	if len(comments) > 0 {
+		writeSidecar(filePath+".comments.json", comments)
+	}
+}
+
+func exportFilePermissions(ctx context.Context, client *driveClient, file *drive.File, filePath string) {
+	var permissions []*drive.Permission
+	var pageToken string
+	for {
+		if error := waitSidecar(ctx, client); error != nil {
+			errorLog.Printf("list permissions of '%s': %s", file.Name, formatAPIError(error))
+			return
+		}
+		permissionList, error := client.service.Permissions.List(file.Id).SupportsAllDrives(true).PageToken(pageToken).Fields("nextPageToken, permissions(id,type,role,emailAddress,domain)").Context(ctx).Do()
+		client.done(error)
+		if error != nil {
+			errorLog.Printf("list permissions of '%s': %s", file.Name, formatAPIError(error))
+			return
+		}
+		permissions = append(permissions, permissionList.Permissions...)
+		pageToken = permissionList.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+	writeSidecar(filePath+".permissions.json", permissions)
+}
+
+func exportFileActivity(ctx context.Context, client *driveClient, file *drive.File, filePath string) {
+	var activities []*driveactivity.DriveActivity
+	request := &driveactivity.QueryDriveActivityRequest{ItemName: "items/" + file.Id, PageSize: 100}
+	for {
+		if error := waitSidecar(ctx, client); error != nil {
+			errorLog.Printf("query activity of '%s': %s", file.Name, formatAPIError(error))
+			return
+		}
+		response, error := activityService.Activity.Query(request).Context(ctx).Do()
+		client.done(error)
+		if error != nil {
+			errorLog.Printf("query activity of '%s': %s", file.Name, formatAPIError(error))
+			return
+		}
+		activities = append(activities, response.Activities...)
+		request.PageToken = response.NextPageToken
+		if request.PageToken == "" {
+			break
+		}
+	}
+	if len(activities) > 0 {
+		writeSidecar(filePath+".activity.json", activities)
+	}
+}
+
+func writeSidecar(path string, value any) {
+	data, error := json.MarshalIndent(value, "", "  ")
+	if error != nil {
+		errorLog.Printf("encode '%s': %v", path, error)
+		return
+	}
+	if error := os.WriteFile(path, data, 0644); error != nil {
+		errorLog.Printf("write '%s': %v", path, error)
+		abortOnDiskError(error)
+	}
+}
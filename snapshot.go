@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var (
+	snapshot     = flag.Bool("snapshot", false, "baixa para uma subpasta de --dest com a data e hora da execução (RFC 3339)")
+	maxSnapshots = flag.Int("max-snapshots", 0, "com --snapshot, mantém apenas os N snapshots mais recentes e apaga os mais antigos (0 mantém todos)")
+)
+
+func pruneSnapshots(root string, keep int) {
+	entries, error := os.ReadDir(root)
+	if error != nil {
+		errorLog.Printf("list snapshots in '%s': %v", root, error)
+		return
+	}
+	var snapshots []time.Time
+	names := map[time.Time]string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		taken, error := time.Parse(time.RFC3339, entry.Name())
+		if error != nil {
+			continue
+		}
+		snapshots = append(snapshots, taken)
+		names[taken] = entry.Name()
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].After(snapshots[j]) })
+	for _, taken := range snapshots[min(keep, len(snapshots)):] {
+		path := filepath.Join(root, names[taken])
+		fmt.Printf("Removendo snapshot antigo: %s\n", path)
+		if error := os.RemoveAll(path); error != nil {
+			errorLog.Printf("remove snapshot '%s': %v", path, error)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	stateDBPath = flag.String("state-db", "", "banco BoltDB com os arquivos já baixados, para retomar execuções interrompidas, ex.: state.bolt")
+	clearState  = flag.Bool("clear-state", false, "com --state-db, apaga o progresso salvo quando a sincronização termina sem falhas")
+)
+
+var (
+	completedBucket = []byte("completed")
+	failedBucket    = []byte("failed")
+	skippedBucket   = []byte("skipped")
+	stateBuckets    = [][]byte{completedBucket, failedBucket, skippedBucket}
+)
+
+var stateDB *bolt.DB
+
+func openStateDB(path string) *bolt.DB {
+	db, error := bolt.Open(path, 0600, nil)
+	if error != nil {
+		fatalf("Não foi possível abrir o banco de estado '%s': %v", path, error)
+	}
+	if error := createStateBuckets(db); error != nil {
+		fatalf("Não foi possível preparar o banco de estado '%s': %v", path, error)
+	}
+	return db
+}
+
+func createStateBuckets(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range stateBuckets {
+			if _, error := tx.CreateBucketIfNotExists(bucket); error != nil {
+				return error
+			}
+		}
+		return nil
+	})
+}
+
+func stateCompleted(job *fileJob) bool {
+	if stateDB == nil || *force || job.replaceExisting {
+		return false
+	}
+	completed := false
+	stateDB.View(func(tx *bolt.Tx) error {
+		modifiedTime := tx.Bucket(completedBucket).Get([]byte(job.file.Id))
+		completed = modifiedTime != nil && (len(modifiedTime) == 0 || string(modifiedTime) == job.file.ModifiedTime)
+		return nil
+	})
+	return completed
+}
+
+func markStateCompleted(job *fileJob) {
+	if stateDB == nil {
+		return
+	}
+	error := stateDB.Batch(func(tx *bolt.Tx) error {
+		if error := tx.Bucket(failedBucket).Delete([]byte(job.file.Id)); error != nil {
+			return error
+		}
+		return tx.Bucket(completedBucket).Put([]byte(job.file.Id), []byte(job.file.ModifiedTime))
+	})
+	if error != nil {
+		errorLog.Printf("save state of '%s': %v", job.file.Name, error)
+	}
+}
+
+func recordState(bucket []byte, job *fileJob, reason string) {
+	if stateDB == nil {
+		return
+	}
+	error := stateDB.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(job.file.Id), []byte(reason))
+	})
+	if error != nil {
+		errorLog.Printf("save state of '%s': %v", job.file.Name, error)
+	}
+}
+
+func clearStateDB() {
+	error := stateDB.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range stateBuckets {
+			if error := tx.DeleteBucket(bucket); error != nil {
+				return error
+			}
+			if _, error := tx.CreateBucket(bucket); error != nil {
+				return error
+			}
+		}
+		return nil
+	})
+	if error != nil {
+		errorLog.Printf("clear state: %v", error)
+	}
+}
+
+func runState(args []string) {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import") {
+		fmt.Fprintln(os.Stderr, "Uso: godrive state export --db state.bolt --out state.json")
+		fmt.Fprintln(os.Stderr, "     godrive state import --db state.bolt --completed ID [ID...]")
+		os.Exit(2)
+	}
+	flagSet := newCommandFlagSet("state " + args[0])
+	dbPath := flagSet.String("db", "state.bolt", "arquivo BoltDB de estado (o mesmo de --state-db)")
+	out := flagSet.String("out", "state.json", "arquivo JSON gerado por state export")
+	completed := flagSet.String("completed", "", "IDs de arquivos a marcar como concluídos em state import, separados por vírgula ou espaço")
+	parseFlags(flagSet, args[1:])
+
+	if args[0] == "export" {
+		if _, error := os.Stat(*dbPath); error != nil {
+			fatalf("Não foi possível abrir o banco de estado '%s': %v", *dbPath, error)
+		}
+	}
+	db := openStateDB(*dbPath)
+	defer db.Close()
+
+	if args[0] == "export" {
+		exportState(db, *out)
+		return
+	}
+	var fileIDs []string
+	for _, fileID := range append(strings.Split(*completed, ","), flagSet.Args()...) {
+		if fileID = strings.TrimSpace(fileID); fileID != "" {
+			fileIDs = append(fileIDs, fileID)
+		}
+	}
+	if len(fileIDs) == 0 {
+		fmt.Fprintln(os.Stderr, "Uso: godrive state import --db state.bolt --completed ID [ID...]")
+		os.Exit(2)
+	}
+	importCompletedState(db, fileIDs)
+	fmt.Printf("%d arquivos marcados como concluídos em '%s'.\n", len(fileIDs), *dbPath)
+}
+
+func exportState(db *bolt.DB, path string) {
+	state := map[string][]string{}
+	db.View(func(tx *bolt.Tx) error {
+		for _, bucket := range stateBuckets {
+			fileIDs := []string{}
+			tx.Bucket(bucket).ForEach(func(key, value []byte) error {
+				fileIDs = append(fileIDs, string(key))
+				return nil
+			})
+			state[string(bucket)] = fileIDs
+		}
+		return nil
+	})
+	data, error := json.MarshalIndent(state, "", "  ")
+	if error != nil {
+		fatalf("Não foi possível gerar o JSON do estado: %v", error)
+	}
+	if error := os.WriteFile(path, data, 0644); error != nil {
+		fatalf("Não foi possível salvar '%s': %v", path, error)
+	}
+	fmt.Printf("Estado exportado para '%s': %d concluídos, %d com falha, %d pulados.\n", path, len(state["completed"]), len(state["failed"]), len(state["skipped"]))
+}
+
+func importCompletedState(db *bolt.DB, fileIDs []string) {
+	error := db.Update(func(tx *bolt.Tx) error {
+		for _, fileID := range fileIDs {
+			if error := tx.Bucket(completedBucket).Put([]byte(fileID), []byte{}); error != nil {
+				return error
+			}
+			if error := tx.Bucket(failedBucket).Delete([]byte(fileID)); error != nil {
+				return error
+			}
+		}
+		return nil
+	})
+	if error != nil {
+		fatalf("Não foi possível atualizar o banco de estado: %v", error)
+	}
+}
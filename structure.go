@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+var structureJSON = flag.Bool("structure-json", false, "após o escaneamento, grava drive_structure.json com a árvore de pastas do Drive (id, nome, caminho e quantidade de arquivos)")
+
+const structureFile = "drive_structure.json"
+
+type structureFolder struct {
+	ID        string             `json:"id"`
+	Name      string             `json:"name"`
+	Path      string             `json:"path"`
+	FileCount int                `json:"fileCount"`
+	Children  []*structureFolder `json:"children"`
+}
+
+type structureRecorder struct {
+	mutex   sync.Mutex
+	folders map[string]*structureFolder
+	roots   []*structureFolder
+}
+
+var structure *structureRecorder
+
+func newStructureRecorder() *structureRecorder {
+	return &structureRecorder{folders: map[string]*structureFolder{}}
+}
+
+func structurePath(localPath string) string {
+	relativePath, error := filepath.Rel(*destinationPath, localPath)
+	if error != nil {
+		relativePath = localPath
+	}
+	return path.Join("/", filepath.ToSlash(relativePath))
+}
+
+func (structure *structureRecorder) addRoot(ctx context.Context, client *driveClient, folderID, localPath string) {
+	if structure == nil {
+		return
+	}
+	name := filepath.Base(localPath)
+	if error := client.wait(ctx); error == nil {
+		folder, error := client.service.Files.Get(folderID).SupportsAllDrives(true).Fields("name").Do()
+		client.done(error)
+		if error == nil {
+			name = folder.Name
+		}
+	}
+	folder := &structureFolder{ID: folderID, Name: name, Path: structurePath(localPath), Children: []*structureFolder{}}
+	structure.mutex.Lock()
+	defer structure.mutex.Unlock()
+	structure.folders[folderID] = folder
+	structure.roots = append(structure.roots, folder)
+}
+
+func (structure *structureRecorder) addFolder(parentID, folderID, name, localPath string) {
+	if structure == nil {
+		return
+	}
+	folder := &structureFolder{ID: folderID, Name: name, Path: structurePath(localPath), Children: []*structureFolder{}}
+	structure.mutex.Lock()
+	defer structure.mutex.Unlock()
+	structure.folders[folderID] = folder
+	if parent, ok := structure.folders[parentID]; ok {
+		parent.Children = append(parent.Children, folder)
+	}
+}
+
+func (structure *structureRecorder) addFile(folderID string) {
+	if structure == nil {
+		return
+	}
+	structure.mutex.Lock()
+	defer structure.mutex.Unlock()
+	if folder, ok := structure.folders[folderID]; ok {
+		folder.FileCount++
+	}
+}
+
+func (structure *structureRecorder) write(filePath string) {
+	if structure == nil {
+		return
+	}
+	structure.mutex.Lock()
+	defer structure.mutex.Unlock()
+	for _, folder := range structure.folders {
+		sort.Slice(folder.Children, func(i, j int) bool { return folder.Children[i].Name < folder.Children[j].Name })
+	}
+	data, error := json.MarshalIndent(structure.roots, "", "  ")
+	if error != nil {
+		errorLog.Printf("encode folder structure: %v", error)
+		return
+	}
+	if error := os.WriteFile(filePath, data, 0644); error != nil {
+		errorLog.Printf("write folder structure '%s': %v", filePath, error)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"time"
+)
+
+var touchOnly = flag.Bool("touch-only", false, "não baixa nada: apenas ajusta a data de modificação dos arquivos locais existentes para a do Drive")
+
+func touchLocalFile(job *fileJob, statusTracker *statusTracker) error {
+	filePath := job.localPath
+	if strings.HasPrefix(job.file.MimeType, "application/vnd.google-apps") {
+		_, extension := exportFormat(job.file.MimeType)
+		if extension == "" {
+			return nil
+		}
+		filePath += extension
+	}
+	info, error := os.Stat(filePath)
+	if error != nil {
+		statusTracker.skip(job, "arquivo não existe localmente")
+		return nil
+	}
+	modifiedTime, error := time.Parse(time.RFC3339, job.file.ModifiedTime)
+	if error != nil {
+		errorLog.Printf("parse modifiedTime of '%s': %v", job.file.Name, error)
+		return nil
+	}
+	if info.ModTime().Equal(modifiedTime) {
+		statusTracker.skip(job, "data de modificação já está correta")
+		return nil
+	}
+	if error := os.Chtimes(filePath, modifiedTime, modifiedTime); error != nil {
+		errorLog.Printf("chtimes '%s': %v", filePath, error)
+		return error
+	}
+	return nil
+}
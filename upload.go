@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+)
+
+type uploadJob struct {
+	localPath string
+	info      os.FileInfo
+	parentID  string
+}
+
+type uploadCounters struct {
+	uploaded atomic.Int32
+	skipped  atomic.Int32
+	failed   atomic.Int32
+}
+
+func runUpload(args []string) {
+	flagSet := newCommandFlagSet("upload")
+	localRoot := flagSet.String("local", "", "diretório local cujos arquivos serão enviados ao Drive")
+	parseFlags(flagSet, args)
+	destinationSet := false
+	flagSet.Visit(func(f *flag.Flag) { destinationSet = destinationSet || f.Name == "dest" })
+	if *localRoot == "" || !destinationSet {
+		fmt.Fprintln(os.Stderr, "Uso: godrive upload --local /caminho/local --dest pasta/no/drive")
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	driveService := authenticateWithScope(ctx, drive.DriveScope)
+	client := newDriveClient(driveService, *apiRequestsPerSecond)
+	rootID, error := ensureDriveFolderPath(ctx, client, *destinationPath)
+	if error != nil {
+		fatalf("Não foi possível criar a pasta de destino no Drive '%s': %v", *destinationPath, error)
+	}
+
+	channelUploadJob := make(chan *uploadJob, 1000)
+	var counters uploadCounters
+	pool := newWorkerPool(channelUploadJob, *minWorkers, *maxWorkers, func(job *uploadJob, heartbeat *workerHeartbeat) {
+		uploadFile(ctx, client, job, &counters)
+	})
+	pool.start()
+
+	error = queueUploads(ctx, client, *localRoot, rootID, channelUploadJob)
+	close(channelUploadJob)
+	pool.wait()
+	if error != nil {
+		fatalf("Não foi possível percorrer '%s': %v", *localRoot, error)
+	}
+	fmt.Printf("Enviados: %d, Pulados: %d, Falhas: %d\n", counters.uploaded.Load(), counters.skipped.Load(), counters.failed.Load())
+	if counters.failed.Load() > 0 {
+		os.Exit(1)
+	}
+}
+
+func queueUploads(ctx context.Context, client *driveClient, localRoot, rootID string, channelUploadJob chan<- *uploadJob) error {
+	localRoot = filepath.Clean(localRoot)
+	folderIDs := map[string]string{localRoot: rootID}
+	return filepath.Walk(localRoot, func(path string, info os.FileInfo, error error) error {
+		if error != nil {
+			errorLog.Printf("walk '%s': %v", path, error)
+			return nil
+		}
+		parentID := folderIDs[filepath.Dir(path)]
+		if info.IsDir() {
+			if _, ok := folderIDs[path]; ok {
+				return nil
+			}
+			folderID, error := ensureDriveFolder(ctx, client, parentID, info.Name())
+			if error != nil {
+				errorLog.Printf("create folder '%s': %s", path, formatAPIError(error))
+				return filepath.SkipDir
+			}
+			folderIDs[path] = folderID
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		channelUploadJob <- &uploadJob{localPath: path, info: info, parentID: parentID}
+		return nil
+	})
+}
+
+func uploadFile(ctx context.Context, client *driveClient, job *uploadJob, counters *uploadCounters) {
+	name := job.info.Name()
+	modifiedTime := job.info.ModTime().UTC().Truncate(time.Second)
+	query := fmt.Sprintf("name='%s' and '%s' in parents and trashed=false and mimeType!='application/vnd.google-apps.folder'", escapeQuery(name), job.parentID)
+	if error := client.wait(ctx); error != nil {
+		errorLog.Printf("upload '%s': %s", job.localPath, formatAPIError(error))
+		counters.failed.Add(1)
+		return
+	}
+	fileList, error := listFiles(client.service).Q(query).Fields("files(id, size, modifiedTime)").PageSize(1).Do()
+	client.done(error)
+	if error != nil {
+		errorLog.Printf("upload '%s': %s", job.localPath, formatAPIError(error))
+		counters.failed.Add(1)
+		return
+	}
+	var existing *drive.File
+	if len(fileList.Files) > 0 {
+		existing = fileList.Files[0]
+		remoteTime, _ := time.Parse(time.RFC3339, existing.ModifiedTime)
+		if existing.Size == job.info.Size() && remoteTime.Truncate(time.Second).Equal(modifiedTime) {
+			skippedLog.Printf("already in Drive '%s'", job.localPath)
+			counters.skipped.Add(1)
+			return
+		}
+	}
+
+	reader, error := os.Open(job.localPath)
+	if error != nil {
+		errorLog.Printf("open '%s': %v", job.localPath, error)
+		counters.failed.Add(1)
+		return
+	}
+	defer reader.Close()
+
+	driveFile := &drive.File{Name: name, ModifiedTime: modifiedTime.Format(time.RFC3339)}
+	if error := client.wait(ctx); error != nil {
+		errorLog.Printf("upload '%s': %s", job.localPath, formatAPIError(error))
+		counters.failed.Add(1)
+		return
+	}
+	if existing != nil {
+		_, error = client.service.Files.Update(existing.Id, driveFile).Media(reader).SupportsAllDrives(true).Context(ctx).Do()
+	} else {
+		driveFile.Parents = []string{job.parentID}
+		_, error = client.service.Files.Create(driveFile).Media(reader).SupportsAllDrives(true).Context(ctx).Do()
+	}
+	client.done(error)
+	if error != nil {
+		errorLog.Printf("upload '%s': %s", job.localPath, formatAPIError(error))
+		counters.failed.Add(1)
+		return
+	}
+	log.Println(job.localPath)
+	counters.uploaded.Add(1)
+}
+
+func ensureDriveFolderPath(ctx context.Context, client *driveClient, path string) (string, error) {
+	folderID := "root"
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		var error error
+		folderID, error = ensureDriveFolder(ctx, client, folderID, part)
+		if error != nil {
+			return "", error
+		}
+	}
+	return folderID, nil
+}
+
+func ensureDriveFolder(ctx context.Context, client *driveClient, parentID, name string) (string, error) {
+	query := fmt.Sprintf("mimeType='application/vnd.google-apps.folder' and name='%s' and '%s' in parents and trashed=false", escapeQuery(name), parentID)
+	if error := client.wait(ctx); error != nil {
+		return "", error
+	}
+	fileList, error := listFiles(client.service).Q(query).Fields("files(id)").PageSize(1).Do()
+	client.done(error)
+	if error != nil {
+		return "", error
+	}
+	if len(fileList.Files) > 0 {
+		return fileList.Files[0].Id, nil
+	}
+	if error := client.wait(ctx); error != nil {
+		return "", error
+	}
+	folder, error := client.service.Files.Create(&drive.File{Name: name, MimeType: "application/vnd.google-apps.folder", Parents: []string{parentID}}).SupportsAllDrives(true).Fields("id").Do()
+	client.done(error)
+	if error != nil {
+		return "", error
+	}
+	return folder.Id, nil
+}
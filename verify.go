@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type manifestEntry struct {
+	path      string
+	sum       string
+	algorithm string
+}
+
+func runVerify(args []string) {
+	flagSet := newCommandFlagSet("verify")
+	manifestName := flagSet.String("manifest", "", "manifesto a verificar: caminho de um arquivo, ou nome dos manifestos procurados em --dest (padrão: "+sha256ManifestFile+" e "+blake3ManifestFile+")")
+	againstDrive := flagSet.Bool("drive", false, "compara os arquivos locais com os MD5 informados pelo Drive em vez de usar os manifestos")
+	parseFlags(flagSet, args)
+
+	if *againstDrive {
+		verifyAgainstDrive()
+	} else {
+		verifyManifests(*manifestName)
+	}
+}
+
+func verifyManifests(manifestName string) {
+	var entries []manifestEntry
+	var error error
+	names := []string{sha256ManifestFile, blake3ManifestFile}
+	if manifestName != "" {
+		names = []string{manifestName}
+	}
+	if info, statError := os.Stat(manifestName); strings.ContainsRune(manifestName, os.PathSeparator) || (statError == nil && !info.IsDir()) {
+		entries, error = readManifest(manifestName)
+	} else {
+		entries, error = readManifests(*destinationPath, names...)
+	}
+	if error != nil {
+		fatalf("Não foi possível ler os manifestos: %v", error)
+	}
+	if len(entries) == 0 {
+		fmt.Printf("Nenhum manifesto (%s) encontrado em '%s'.\n", strings.Join(names, ", "), *destinationPath)
+		return
+	}
+
+	var mismatches []string
+	for index, entry := range entries {
+		sum, error := hashFile(entry.path, newHash(entry.algorithm))
+		if error != nil {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %v", entry.path, error))
+		} else if sum != entry.sum {
+			mismatches = append(mismatches, fmt.Sprintf("%s: hash diferente (esperado %s, obtido %s)", entry.path, entry.sum, sum))
+		}
+		printProgressBar(index+1, len(entries))
+	}
+	fmt.Println()
+
+	for _, mismatch := range mismatches {
+		fmt.Println("✗ " + mismatch)
+	}
+	fmt.Printf("%d arquivos verificados, %d com problema.\n", len(entries), len(mismatches))
+	if len(mismatches) > 0 {
+		os.Exit(1)
+	}
+}
+
+func verifyAgainstDrive() {
+	ctx := context.Background()
+	driveService := authenticate(ctx)
+	client := newDriveClient(driveService, *apiRequestsPerSecond)
+	folderID, error := getDriveFolderIDByPath(driveService, *sourcePath)
+	if error != nil {
+		fatalf("ERRO: %v", error)
+	}
+
+	fmt.Printf("Listando os arquivos de '%s' no Drive...\n", *sourcePath)
+	checksums := map[string]string{}
+	if error := listDriveChecksums(ctx, client, folderID, *destinationPath, *destinationPath, checksums); error != nil {
+		fatalf("Não foi possível listar os arquivos do Drive: %v", error)
+	}
+
+	localFiles, error := listLocalFiles(*destinationPath)
+	if error != nil && !os.IsNotExist(error) {
+		fatalf("Não foi possível listar os arquivos em '%s': %v", *destinationPath, error)
+	}
+
+	var different, missing, extra []string
+	found := map[string]bool{}
+	for index, path := range localFiles {
+		found[path] = true
+		expected, inDrive := checksums[path]
+		if !inDrive {
+			extra = append(extra, path)
+		} else if expected != "" {
+			sum, error := hashFile(path, md5.New())
+			if error != nil {
+				different = append(different, fmt.Sprintf("%s: %v", path, error))
+			} else if sum != expected {
+				different = append(different, fmt.Sprintf("%s: MD5 diferente (esperado %s, obtido %s)", path, expected, sum))
+			}
+		}
+		printProgressBar(index+1, len(localFiles))
+	}
+	fmt.Println()
+	for path := range checksums {
+		if !found[path] {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+
+	for _, path := range different {
+		fmt.Println("✗ " + path)
+	}
+	for _, path := range missing {
+		fmt.Println("✗ ausente localmente: " + path)
+	}
+	for _, path := range extra {
+		fmt.Println("✗ não existe no Drive: " + path)
+	}
+	fmt.Printf("%d arquivos no Drive, %d locais: %d diferentes, %d ausentes localmente, %d que não existem no Drive.\n", len(checksums), len(localFiles), len(different), len(missing), len(extra))
+	if len(different)+len(missing)+len(extra) > 0 {
+		os.Exit(1)
+	}
+}
+
+func listLocalFiles(root string) ([]string, error) {
+	var files []string
+	error := filepath.WalkDir(root, func(path string, entry fs.DirEntry, error error) error {
+		if error != nil {
+			return error
+		}
+		if !entry.IsDir() && !isLocalArtifact(entry.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, error
+}
+
+var localArtifactNames = map[string]bool{sha256ManifestFile: true, blake3ManifestFile: true, "skipped.log": true, "error.log": true, "restricted.log": true}
+
+var localArtifactSuffixes = []string{".tmp", ".bak", ".comments.json", ".permissions.json", ".activity.json", ".labels.json"}
+
+func isLocalArtifact(name string) bool {
+	if localArtifactNames[name] {
+		return true
+	}
+	for _, suffix := range localArtifactSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func listDriveChecksums(ctx context.Context, client *driveClient, folderID, rootPath, folderLocalPath string, checksums map[string]string) error {
+	query := fmt.Sprintf("'%s' in parents and trashed=false", folderID)
+	if *starred {
+		query += " and (starred=true or mimeType='application/vnd.google-apps.folder')"
+	}
+	var pageToken string
+	for {
+		if error := client.wait(ctx); error != nil {
+			return error
+		}
+		fileList, error := listFiles(client.service).Q(query).PageSize(*pageSize).Fields("nextPageToken, files(" + fileFields() + ")").PageToken(pageToken).Do()
+		client.done(error)
+		if error != nil {
+			return error
+		}
+		for _, file := range fileList.Files {
+			if file.MimeType == "application/vnd.google-apps.folder" {
+				if error := listDriveChecksums(ctx, client, file.Id, rootPath, filepath.Join(folderLocalPath, sanitizeFileName(file.Name)), checksums); error != nil {
+					return error
+				}
+				continue
+			}
+			useOriginalFilename(file)
+			localPath := fileLocalPath(file, folderID, rootPath, folderLocalPath)
+			if strings.HasPrefix(file.MimeType, "application/vnd.google-apps") {
+				_, extension := exportFormat(file.MimeType)
+				if extension == "" {
+					continue
+				}
+				localPath += extension
+			}
+			checksums[localPath] = file.Md5Checksum
+		}
+		pageToken = fileList.NextPageToken
+		if pageToken == "" {
+			return nil
+		}
+	}
+}
+
+func readManifests(root string, manifestNames ...string) ([]manifestEntry, error) {
+	var entries []manifestEntry
+	error := filepath.WalkDir(root, func(path string, entry fs.DirEntry, error error) error {
+		if error != nil {
+			return error
+		}
+		if entry.IsDir() || !slices.Contains(manifestNames, entry.Name()) {
+			return nil
+		}
+		manifestEntries, error := readManifest(path)
+		entries = append(entries, manifestEntries...)
+		return error
+	})
+	return entries, error
+}
+
+func readManifest(manifestPath string) ([]manifestEntry, error) {
+	manifest, error := os.Open(manifestPath)
+	if error != nil {
+		return nil, error
+	}
+	defer manifest.Close()
+
+	var entries []manifestEntry
+	indexes := map[string]int{}
+	algorithm := "sha256"
+	scanner := bufio.NewScanner(manifest)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "# Algorithm:"); ok {
+			algorithm = strings.TrimSpace(header)
+			if newHash(algorithm) == nil {
+				return nil, fmt.Errorf("algoritmo '%s' desconhecido em '%s'", algorithm, manifestPath)
+			}
+			continue
+		}
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry := manifestEntry{path: filepath.Join(filepath.Dir(manifestPath), name), sum: sum, algorithm: algorithm}
+		if index, ok := indexes[name]; ok {
+			entries[index] = entry
+			continue
+		}
+		indexes[name] = len(entries)
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func hashFile(path string, hash hash.Hash) (string, error) {
+	file, error := os.Open(path)
+	if error != nil {
+		return "", error
+	}
+	defer file.Close()
+	if _, error := io.Copy(hash, file); error != nil {
+		return "", error
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func printProgressBar(done, total int) {
+	const width = 40
+	filled := done * width / total
+	fmt.Printf("\r[%s%s] %3d%% (%d/%d)", strings.Repeat("#", filled), strings.Repeat("-", width-filled), done*100/total, done, total)
+}
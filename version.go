@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+func runVersion(args []string) {
+	parseFlags(newCommandFlagSet("version"), args)
+
+	commit, buildDate := Commit, BuildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && commit == "":
+				commit = setting.Value
+			case setting.Key == "vcs.time" && buildDate == "":
+				buildDate = setting.Value
+			}
+		}
+	}
+	if commit == "" {
+		commit = "desconhecido"
+	}
+	if buildDate == "" {
+		buildDate = "desconhecida"
+	}
+
+	fmt.Printf("godrive %s\n", Version)
+	fmt.Printf("Commit:     %s\n", commit)
+	fmt.Printf("Compilação: %s\n", buildDate)
+	fmt.Printf("Go:         %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	watch              = flag.Bool("watch", false, "após a sincronização inicial, continua observando o Drive e sincroniza periodicamente")
+	pollInterval       = flag.Duration("poll-interval", 15*time.Minute, "intervalo entre sincronizações no modo --watch")
+	incrementalChanges = flag.Bool("incremental-changes", false, "no modo --watch, baixa apenas o que mudou usando a API de alterações do Drive")
+)
+
+func watchForChanges(ctx context.Context, client *driveClient, folderID, pageToken string) {
+	rescan := make(chan os.Signal, 1)
+	notifyRescan(rescan)
+
+	resolver := newFolderPathResolver(ctx, client, folderID, *destinationPath)
+	for {
+		waitForNextSync(ctx, time.Now().Add(*pollInterval), rescan)
+		if ctx.Err() != nil {
+			return
+		}
+		if *incrementalChanges {
+			pageToken = syncChanges(ctx, client, resolver, pageToken)
+		} else {
+			syncFolder(ctx, client, folderID)
+		}
+	}
+}
+
+func waitForNextSync(ctx context.Context, nextSync time.Time, rescan <-chan os.Signal) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		remaining := time.Until(nextSync)
+		if remaining <= 0 {
+			fmt.Println()
+			return
+		}
+		fmt.Printf("\rObservando (próxima sincronização em %s)          ", remaining.Round(time.Second))
+		select {
+		case <-ctx.Done():
+			return
+		case <-rescan:
+			fmt.Println("\nSinal recebido, sincronizando agora...")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func syncChanges(ctx context.Context, client *driveClient, resolver *folderPathResolver, pageToken string) string {
+	runSync(ctx, client, func(channelFileJob chan<- *fileJob, statusTracker *statusTracker) {
+		for pageToken != "" {
+			if error := client.wait(ctx); error != nil {
+				log.Printf("ao listar alterações do Drive: %s", formatAPIError(error))
+				return
+			}
+			changeList, error := client.service.Changes.List(pageToken).IncludeItemsFromAllDrives(true).SupportsAllDrives(true).PageSize(*pageSize).Fields("nextPageToken, newStartPageToken, changes(fileId, removed, file(" + fileFields() + ", parents, trashed, starred))").Do()
+			client.done(error)
+			if error != nil {
+				log.Printf("ao listar alterações do Drive: %s", formatAPIError(error))
+				return
+			}
+			for _, change := range changeList.Changes {
+				file := change.File
+				if change.Removed || file == nil || (file.Trashed && !*trashed && !*trashedOnly) || (*trashedOnly && !file.Trashed) || file.MimeType == "application/vnd.google-apps.folder" || len(file.Parents) == 0 || (*starred && !file.Starred) {
+					continue
+				}
+				useOriginalFilename(file)
+				parentID := file.Parents[0]
+				localFolder, inside, error := resolver.localFolder(ctx, parentID)
+				if error != nil {
+					log.Printf("ao resolver a pasta de '%s': %s", file.Name, formatAPIError(error))
+					continue
+				}
+				if !inside {
+					continue
+				}
+				job := &fileJob{file: file, localPath: fileLocalPath(file, parentID, resolver.localRoot, localFolder), replaceExisting: true}
+				if file.Trashed {
+					job.localPath = trashLocalPath(resolver.localRoot, job.localPath)
+				}
+				statusTracker.totalFilesFound.Add(1)
+				channelFileJob <- job
+			}
+			if changeList.NewStartPageToken != "" {
+				pageToken = changeList.NewStartPageToken
+				return
+			}
+			pageToken = changeList.NextPageToken
+		}
+	})
+	return pageToken
+}
+
+type folderPathResolver struct {
+	client     *driveClient
+	rootID     string
+	localRoot  string
+	localPaths map[string]string
+}
+
+func newFolderPathResolver(ctx context.Context, client *driveClient, rootID, localRoot string) *folderPathResolver {
+	if rootID == "root" {
+		root, error := client.service.Files.Get("root").SupportsAllDrives(true).Fields("id").Do()
+		if error != nil {
+			fatalf("Não foi possível obter a pasta raiz do Drive: %v", error)
+		}
+		rootID = root.Id
+	}
+	return &folderPathResolver{client: client, rootID: rootID, localRoot: localRoot, localPaths: map[string]string{}}
+}
+
+func (resolver *folderPathResolver) localFolder(ctx context.Context, folderID string) (string, bool, error) {
+	if folderID == resolver.rootID {
+		return resolver.localRoot, true, nil
+	}
+	if localPath, ok := resolver.localPaths[folderID]; ok {
+		return localPath, localPath != "", nil
+	}
+	if error := resolver.client.wait(ctx); error != nil {
+		return "", false, error
+	}
+	folder, error := resolver.client.service.Files.Get(folderID).SupportsAllDrives(true).Fields("id, name, parents").Do()
+	resolver.client.done(error)
+	if error != nil {
+		return "", false, error
+	}
+	localPath := ""
+	if len(folder.Parents) > 0 {
+		parentPath, inside, error := resolver.localFolder(ctx, folder.Parents[0])
+		if error != nil {
+			return "", false, error
+		}
+		if inside {
+			localPath = filepath.Join(parentPath, sanitizeFileName(folder.Name))
+		}
+	}
+	resolver.localPaths[folderID] = localPath
+	return localPath, localPath != "", nil
+}
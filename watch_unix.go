@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func notifyRescan(rescan chan<- os.Signal) {
+	signal.Notify(rescan, syscall.SIGUSR1)
+}
@@ -0,0 +1,5 @@
+package main
+
+import "os"
+
+func notifyRescan(rescan chan<- os.Signal) {}
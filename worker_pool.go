@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	minWorkers     = flag.Int("min-workers", 10, "quantidade de workers de download iniciados em cada sincronização")
+	maxWorkers     = flag.Int("max-workers", 1000, "quantidade máxima de workers de download criados quando a fila cresce")
+	scaleDownDelay = flag.Duration("scale-down-delay", 30*time.Second, "tempo com a fila vazia até os workers excedentes serem encerrados")
+	workerTimeout  = flag.Duration("worker-timeout", 10*time.Minute, "tempo sem receber dados até um download ser considerado travado e reiniciado")
+	workerStats    = flag.Bool("worker-stats", false, "ao final de cada sincronização, mostra quantos arquivos, erros e bytes cada worker processou")
+)
+
+type workerPool[J any] struct {
+	jobs       <-chan J
+	handle     func(job J, heartbeat *workerHeartbeat)
+	minWorkers int
+	maxWorkers int
+	active     atomic.Int32
+	heartbeats []*workerHeartbeat
+	freeIDs    chan int
+	waitGroup  sync.WaitGroup
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+type workerHeartbeat struct {
+	stats            workerStatistics
+	lastActivityTime atomic.Int64
+	mutex            sync.Mutex
+	cancel           context.CancelFunc
+}
+
+type workerStatistics struct {
+	filesDownloaded atomic.Int64
+	errors          atomic.Int64
+	bytesDownloaded atomic.Int64
+}
+
+type heartbeatKey struct{}
+
+type activityReader struct {
+	reader    io.Reader
+	heartbeat *workerHeartbeat
+}
+
+func newWorkerPool[J any](jobs <-chan J, minWorkers, maxWorkers int, handle func(job J, heartbeat *workerHeartbeat)) *workerPool[J] {
+	maxWorkers = max(minWorkers, maxWorkers)
+	pool := &workerPool[J]{
+		jobs:       jobs,
+		handle:     handle,
+		minWorkers: minWorkers,
+		maxWorkers: maxWorkers,
+		heartbeats: make([]*workerHeartbeat, maxWorkers),
+		freeIDs:    make(chan int, maxWorkers),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for workerID := range maxWorkers {
+		pool.heartbeats[workerID] = &workerHeartbeat{}
+		pool.freeIDs <- workerID
+	}
+	return pool
+}
+
+func (pool *workerPool[J]) start() {
+	for range pool.minWorkers {
+		pool.spawn()
+	}
+	go pool.monitor()
+	go pool.supervise()
+}
+
+func (pool *workerPool[J]) wait() {
+	pool.waitGroup.Wait()
+	close(pool.done)
+}
+
+func (pool *workerPool[J]) spawn() {
+	select {
+	case workerID := <-pool.freeIDs:
+		pool.active.Add(1)
+		pool.waitGroup.Add(1)
+		go pool.work(workerID)
+	default:
+	}
+}
+
+func (pool *workerPool[J]) work(workerID int) {
+	defer pool.waitGroup.Done()
+	defer pool.active.Add(-1)
+	defer func() { pool.freeIDs <- workerID }()
+	for {
+		select {
+		case <-pool.stop:
+			return
+		case job, ok := <-pool.jobs:
+			if !ok {
+				return
+			}
+			pool.handle(job, pool.heartbeats[workerID])
+		}
+	}
+}
+
+func (pool *workerPool[J]) monitor() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	emptySince := time.Now()
+	for {
+		select {
+		case <-pool.done:
+			return
+		case <-ticker.C:
+		}
+
+		queueDepth := len(pool.jobs)
+		active := int(pool.active.Load())
+		if queueDepth > 0 {
+			emptySince = time.Now()
+		}
+		if queueDepth > active*2 {
+			for ; active < pool.maxWorkers && queueDepth > active*2; active++ {
+				pool.spawn()
+			}
+			continue
+		}
+		if queueDepth == 0 && time.Since(emptySince) >= *scaleDownDelay {
+			for ; active > pool.minWorkers; active-- {
+				select {
+				case pool.stop <- struct{}{}:
+					continue
+				default:
+				}
+				break
+			}
+		}
+	}
+}
+
+func (pool *workerPool[J]) supervise() {
+	ticker := time.NewTicker(max(*workerTimeout/2, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pool.done:
+			return
+		case <-ticker.C:
+		}
+		for workerID, heartbeat := range pool.heartbeats {
+			idle := time.Since(time.Unix(0, heartbeat.lastActivityTime.Load()))
+			heartbeat.mutex.Lock()
+			if heartbeat.cancel != nil && idle > *workerTimeout {
+				errorLog.Printf("worker %d stuck for %s, restarting its download", workerID, idle.Round(time.Second))
+				heartbeat.cancel()
+				heartbeat.cancel = nil
+			}
+			heartbeat.mutex.Unlock()
+		}
+	}
+}
+
+func (heartbeat *workerHeartbeat) begin(ctx context.Context) (context.Context, func()) {
+	heartbeat.touch()
+	jobCtx, cancel := context.WithCancel(context.WithValue(ctx, heartbeatKey{}, heartbeat))
+	heartbeat.mutex.Lock()
+	heartbeat.cancel = cancel
+	heartbeat.mutex.Unlock()
+	return jobCtx, func() {
+		heartbeat.mutex.Lock()
+		heartbeat.cancel = nil
+		heartbeat.mutex.Unlock()
+		cancel()
+	}
+}
+
+func (heartbeat *workerHeartbeat) touch() {
+	heartbeat.lastActivityTime.Store(time.Now().UnixNano())
+}
+
+func withActivity(ctx context.Context, reader io.Reader) io.Reader {
+	if heartbeat, ok := ctx.Value(heartbeatKey{}).(*workerHeartbeat); ok {
+		return &activityReader{reader: reader, heartbeat: heartbeat}
+	}
+	return reader
+}
+
+func (reader *activityReader) Read(p []byte) (int, error) {
+	n, error := reader.reader.Read(p)
+	reader.heartbeat.stats.bytesDownloaded.Add(int64(n))
+	reader.heartbeat.touch()
+	return n, error
+}
+
+func (pool *workerPool[J]) printStats() {
+	var workerIDs []int
+	for workerID, heartbeat := range pool.heartbeats {
+		if heartbeat.stats.filesDownloaded.Load() > 0 || heartbeat.stats.errors.Load() > 0 {
+			workerIDs = append(workerIDs, workerID)
+		}
+	}
+	sort.SliceStable(workerIDs, func(i, j int) bool {
+		return pool.heartbeats[workerIDs[i]].stats.filesDownloaded.Load() > pool.heartbeats[workerIDs[j]].stats.filesDownloaded.Load()
+	})
+	fmt.Printf("%-8s %10s %8s %12s\n", "Worker", "Arquivos", "Erros", "MB")
+	for _, workerID := range workerIDs {
+		stats := &pool.heartbeats[workerID].stats
+		fmt.Printf("%-8d %10d %8d %12.2f\n", workerID, stats.filesDownloaded.Load(), stats.errors.Load(), float64(stats.bytesDownloaded.Load())/(1<<20))
+	}
+}